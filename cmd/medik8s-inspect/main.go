@@ -0,0 +1,210 @@
+// medik8s-inspect is a read-only diagnostic tool for support engineers. It inspects medik8s state in a cluster
+// using the same code paths as the operators.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	"github.com/medik8s/common/pkg/etcd"
+	leaseutil "github.com/medik8s/common/pkg/lease"
+	"github.com/medik8s/common/pkg/nodes"
+	"github.com/medik8s/common/pkg/remediation"
+	"github.com/medik8s/common/pkg/templates"
+)
+
+const usage = `Usage: medik8s-inspect <command> [flags]
+
+Commands:
+  etcd           decide whether control plane nodes can be disrupted without risking etcd quorum
+  leases         list the leases in a namespace with their holder and expiry
+  remediations   summarize the remediation CRs targeting a node
+`
+
+func main() {
+	os.Exit(run())
+}
+
+// run returns the exit code, so that deferred calls run before main exits
+func run() int {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var err error
+	switch os.Args[1] {
+	case "etcd":
+		err = runEtcd(ctx, os.Args[2:])
+	case "leases":
+		err = runLeases(ctx, os.Args[2:])
+	case "remediations":
+		err = runRemediations(ctx, os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func runEtcd(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("etcd", flag.ExitOnError)
+	nodeName := flags.String("node", "", "name of the node, all control plane nodes if empty")
+	namespace := flags.String("namespace", etcd.EtcdNamespace, "namespace of the etcd guard PDB and pods")
+	_ = flags.Parse(args)
+
+	cl, err := newClient()
+	if err != nil {
+		return err
+	}
+	var checked []*corev1.Node
+	if *nodeName != "" {
+		node := &corev1.Node{}
+		if err := cl.Get(ctx, client.ObjectKey{Name: *nodeName}, node); err != nil {
+			return fmt.Errorf("failed to get node %s: %w", *nodeName, err)
+		}
+		checked = append(checked, node)
+	} else {
+		nodeList := &corev1.NodeList{}
+		if err := cl.List(ctx, nodeList); err != nil {
+			return fmt.Errorf("failed to list nodes: %w", err)
+		}
+		for i := range nodeList.Items {
+			if nodes.IsControlPlane(&nodeList.Items[i]) {
+				checked = append(checked, &nodeList.Items[i])
+			}
+		}
+	}
+
+	checker := etcd.NewEtcdChecker(cl, etcd.Options{Namespace: *namespace})
+	if _, err := checker.IsDisruptionAllowedForNodes(ctx, checked); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tALLOWED\tREASON\tPDB\tDISRUPTIONS ALLOWED\tGUARD POD")
+	for _, decision := range checker.LastDecisions() {
+		fmt.Fprintf(w, "%s\t%t\t%s\t%s\t%d\t%s\n", decision.NodeName, decision.Allowed, decision.Reason, decision.PDB,
+			decision.DisruptionsAllowed, decision.GuardPod)
+	}
+	return w.Flush()
+}
+
+func runLeases(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("leases", flag.ExitOnError)
+	namespace := flags.String("namespace", "medik8s-leases", "namespace of the leases")
+	_ = flags.Parse(args)
+
+	cl, err := newClient()
+	if err != nil {
+		return err
+	}
+	leases := &coordv1.LeaseList{}
+	if err := cl.List(ctx, leases, client.InNamespace(*namespace)); err != nil {
+		return fmt.Errorf("failed to list leases: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tHOLDER\tRENEWED\tEXPIRES\tVALID")
+	now := time.Now()
+	for _, lease := range leases.Items {
+		holder, renewed, expires, valid := "<none>", "<unknown>", "<unknown>", false
+		if lease.Spec.HolderIdentity != nil {
+			holder = *lease.Spec.HolderIdentity
+		}
+		if lease.Spec.RenewTime != nil {
+			renewed = lease.Spec.RenewTime.Format(time.RFC3339)
+		} else if lease.Spec.AcquireTime != nil {
+			renewed = lease.Spec.AcquireTime.Format(time.RFC3339)
+		}
+		if dueTime, ok := leaseutil.LeaseExpiry(&lease); ok {
+			expires = dueTime.Format(time.RFC3339)
+			valid = dueTime.After(now)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\n", lease.Name, holder, renewed, expires, valid)
+	}
+	return w.Flush()
+}
+
+func runRemediations(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("remediations", flag.ExitOnError)
+	nodeName := flags.String("node", "", "name of the node (required)")
+	_ = flags.Parse(args)
+	if *nodeName == "" {
+		return fmt.Errorf("--node is required")
+	}
+
+	cl, err := newClient()
+	if err != nil {
+		return err
+	}
+	kinds, err := templates.DiscoverKinds(ctx, cl)
+	if err != nil {
+		return err
+	}
+	remediationKinds := make([]schema.GroupVersionKind, 0, len(kinds))
+	for _, kind := range kinds {
+		remediationKinds = append(remediationKinds, kind.RemediationGVK())
+	}
+
+	summary, err := remediation.SummarizeForNode(ctx, cl, remediationKinds, *nodeName)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "STATE\tKIND\tNAMESPACE\tNAME")
+	for _, group := range []struct {
+		state remediation.State
+		refs  []corev1.ObjectReference
+	}{
+		{remediation.StateInProgress, summary.InProgress},
+		{remediation.StateSucceeded, summary.Succeeded},
+		{remediation.StateFailed, summary.Failed},
+		{remediation.StateTimedOut, summary.TimedOut},
+	} {
+		for _, ref := range group.refs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", group.state, ref.Kind, ref.Namespace, ref.Name)
+		}
+	}
+	return w.Flush()
+}
+
+func newClient() (client.Client, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig: %w", err)
+	}
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := apiextensionsv1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	cl, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return cl, nil
+}
@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const pollInterval = 2 * time.Second
+
+// IsDaemonSetReady returns true if the DaemonSet's latest generation is rolled out and available on all
+// scheduled nodes
+func IsDaemonSetReady(ds *appsv1.DaemonSet) bool {
+	return ds.Status.ObservedGeneration >= ds.Generation &&
+		ds.Status.UpdatedNumberScheduled == ds.Status.DesiredNumberScheduled &&
+		ds.Status.NumberAvailable == ds.Status.DesiredNumberScheduled
+}
+
+// WaitForDaemonSetReady waits until the DaemonSet is ready, or returns an error after the timeout.
+func WaitForDaemonSetReady(ctx context.Context, cl client.Reader, key client.ObjectKey, timeout time.Duration) error {
+	ds := &appsv1.DaemonSet{}
+	err := wait.PollUntilContextTimeout(ctx, pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		if err := cl.Get(ctx, key, ds); err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+		return IsDaemonSetReady(ds), nil
+	})
+	if err != nil {
+		return fmt.Errorf("DaemonSet %s isn't ready, %d of %d agents available: %w",
+			key, ds.Status.NumberAvailable, ds.Status.DesiredNumberScheduled, err)
+	}
+	return nil
+}
+
+// IsAgentRunningOnNode returns true if a ready pod of the DaemonSet runs on the given node.
+// The pods are looked up by the DaemonSet's selector in its namespace.
+func IsAgentRunningOnNode(ctx context.Context, cl client.Reader, dsKey client.ObjectKey, nodeName string) (bool, error) {
+	ds := &appsv1.DaemonSet{}
+	if err := cl.Get(ctx, dsKey, ds); err != nil {
+		return false, fmt.Errorf("failed to get DaemonSet %s: %w", dsKey, err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(ds.Spec.Selector)
+	if err != nil {
+		return false, fmt.Errorf("invalid selector of DaemonSet %s: %w", dsKey, err)
+	}
+	pods := &corev1.PodList{}
+	if err := cl.List(ctx, pods, client.InNamespace(dsKey.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return false, fmt.Errorf("failed to list pods of DaemonSet %s: %w", dsKey, err)
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Spec.NodeName != nodeName || pod.DeletionTimestamp != nil || !isOwnedBy(pod, ds) {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodRunning && isPodReady(pod) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isOwnedBy(pod *corev1.Pod, ds *appsv1.DaemonSet) bool {
+	for _, ref := range pod.OwnerReferences {
+		if ref.UID == ds.UID {
+			return true
+		}
+	}
+	return false
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
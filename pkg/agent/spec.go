@@ -0,0 +1,174 @@
+// Package agent contains building blocks for the DaemonSets of privileged medik8s node agents,
+// like the self node remediation agent.
+package agent
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+
+	"github.com/medik8s/common/pkg/nodes"
+)
+
+const (
+	// RequiredSCCAnnotation pins the OpenShift SecurityContextConstraints used for the agent pods
+	RequiredSCCAnnotation = "openshift.io/required-scc"
+	// PrivilegedSCC is the name of the OpenShift privileged SCC
+	PrivilegedSCC = "privileged"
+	// NodeCriticalPriorityClass is the priority class of agents which are needed for keeping a node healthy
+	NodeCriticalPriorityClass = "system-node-critical"
+
+	// DevVolumeName is the name of the volume exposing the host's /dev, which contains the watchdog devices
+	DevVolumeName = "host-dev"
+	// DevPath is the host and container path of the /dev volume
+	DevPath = "/dev"
+)
+
+// Options configure the agent pod spec
+type Options struct {
+	// ServiceAccountName of the agent pods
+	ServiceAccountName string
+	// Containers of the agent pods, which are made privileged and get the /dev mount if Watchdog is set
+	Containers []corev1.Container
+	// HostPID shares the host's PID namespace
+	HostPID bool
+	// Watchdog mounts the host's /dev into all containers
+	Watchdog bool
+	// PriorityClassName defaults to NodeCriticalPriorityClass
+	PriorityClassName string
+	// NodeSelector restricts the agents to some nodes, by default they run on all nodes
+	NodeSelector map[string]string
+}
+
+// PrivilegedSecurityContext returns the security context of privileged agent containers
+func PrivilegedSecurityContext() *corev1.SecurityContext {
+	return &corev1.SecurityContext{
+		Privileged: ptr.To(true),
+		RunAsUser:  ptr.To(int64(0)),
+	}
+}
+
+// WatchdogVolume returns the volume and the mount which expose the host's /dev, including the watchdog devices
+func WatchdogVolume() (corev1.Volume, corev1.VolumeMount) {
+	volume := corev1.Volume{
+		Name: DevVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{
+				Path: DevPath,
+				Type: ptr.To(corev1.HostPathDirectory),
+			},
+		},
+	}
+	mount := corev1.VolumeMount{
+		Name:      DevVolumeName,
+		MountPath: DevPath,
+	}
+	return volume, mount
+}
+
+// ControlPlaneTolerations returns the tolerations needed for running on control plane nodes
+func ControlPlaneTolerations() []corev1.Toleration {
+	return []corev1.Toleration{
+		{
+			Key:      nodes.ControlPlaneRoleLabel,
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		},
+		{
+			Key:      nodes.MasterRoleLabel,
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		},
+	}
+}
+
+// UnhealthyNodeTolerations returns the tolerations which keep agents running on unhealthy and cordoned nodes,
+// where they are needed most
+func UnhealthyNodeTolerations() []corev1.Toleration {
+	return []corev1.Toleration{
+		{
+			Key:      corev1.TaintNodeNotReady,
+			Operator: corev1.TolerationOpExists,
+		},
+		{
+			Key:      corev1.TaintNodeUnreachable,
+			Operator: corev1.TolerationOpExists,
+		},
+		{
+			Key:      corev1.TaintNodeUnschedulable,
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		},
+	}
+}
+
+// PodAnnotations returns the annotations of privileged agent pods
+func PodAnnotations() map[string]string {
+	return map[string]string{
+		RequiredSCCAnnotation: PrivilegedSCC,
+	}
+}
+
+// NewPodSpec assembles the pod spec of a privileged agent
+func NewPodSpec(opts Options) corev1.PodSpec {
+	priorityClassName := opts.PriorityClassName
+	if priorityClassName == "" {
+		priorityClassName = NodeCriticalPriorityClass
+	}
+
+	containers := make([]corev1.Container, 0, len(opts.Containers))
+	var volumes []corev1.Volume
+	var watchdogMount corev1.VolumeMount
+	if opts.Watchdog {
+		var watchdogVolume corev1.Volume
+		watchdogVolume, watchdogMount = WatchdogVolume()
+		volumes = append(volumes, watchdogVolume)
+	}
+	for _, container := range opts.Containers {
+		container = *container.DeepCopy()
+		container.SecurityContext = PrivilegedSecurityContext()
+		if opts.Watchdog {
+			container.VolumeMounts = append(container.VolumeMounts, watchdogMount)
+		}
+		containers = append(containers, container)
+	}
+
+	return corev1.PodSpec{
+		ServiceAccountName: opts.ServiceAccountName,
+		HostPID:            opts.HostPID,
+		PriorityClassName:  priorityClassName,
+		NodeSelector:       opts.NodeSelector,
+		Tolerations:        append(ControlPlaneTolerations(), UnhealthyNodeTolerations()...),
+		Containers:         containers,
+		Volumes:            volumes,
+	}
+}
+
+// NewDaemonSet wraps the pod spec into a DaemonSet. labels are used as selector and pod labels.
+func NewDaemonSet(namespace, name string, labels map[string]string, podSpec corev1.PodSpec) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    labels,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			UpdateStrategy: appsv1.DaemonSetUpdateStrategy{
+				Type: appsv1.RollingUpdateDaemonSetStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDaemonSet{
+					MaxUnavailable: ptr.To(intstr.FromString("10%")),
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: PodAnnotations(),
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+}
@@ -0,0 +1,10 @@
+// Package annotations contains the annotation keys shared by medik8s operators.
+package annotations
+
+const (
+	// NodeNameAnnotation is set on remediation CRs and contains the name of the node they remediate.
+	// It is needed because remediation CRs aren't necessarily named after the node.
+	NodeNameAnnotation = "remediation.medik8s.io/node-name"
+	// NhcTimedOutAnnotation is set by NHC on remediation CRs which didn't succeed in time
+	NhcTimedOutAnnotation = "remediation.medik8s.io/nhc-timed-out"
+)
@@ -0,0 +1,189 @@
+// Package apicheck periodically probes the API server and keeps a history of the results,
+// which lets self fencing agents decide whether they are the isolated party.
+package apicheck
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/medik8s/common/pkg/logging"
+)
+
+const (
+	// DefaultInterval is the default interval between probes
+	DefaultInterval = 10 * time.Second
+	// DefaultTimeout is the default timeout of a single probe
+	DefaultTimeout = 5 * time.Second
+	// DefaultHistorySize is the default number of probe results kept
+	DefaultHistorySize = 100
+)
+
+var (
+	log = ctrl.Log.WithName("apicheck")
+	// probes fail on every interval while the API server is unreachable
+	probeErrorLog = logging.Every(time.Minute)
+)
+
+// ProbeFunc checks connectivity to the API server
+type ProbeFunc func(ctx context.Context) error
+
+// Sample is the result of a single probe
+type Sample struct {
+	Time time.Time
+	Err  error
+}
+
+// Success returns true if the probe succeeded
+func (s Sample) Success() bool {
+	return s.Err == nil
+}
+
+// Checker probes the API server periodically after it was started
+type Checker struct {
+	probe    ProbeFunc
+	interval time.Duration
+	timeout  time.Duration
+
+	lock sync.RWMutex
+	// history is a ring buffer, next points to the slot of the next sample
+	history []Sample
+	next    int
+	count   int
+	// lastSuccess and lastFailure are kept separately, because they might have been rotated out of history
+	lastSuccess time.Time
+	lastFailure time.Time
+	// streakStart is the time of the first sample of the current streak of equal results
+	streakStart time.Time
+}
+
+var _ manager.Runnable = &Checker{}
+
+// NewChecker creates a Checker which probes the API server's readyz endpoint. restClient is usually the RESTClient()
+// of a discovery client. Non positive values are replaced by the defaults.
+func NewChecker(restClient rest.Interface, interval, timeout time.Duration, historySize int) *Checker {
+	return NewCheckerWithProbe(func(ctx context.Context) error {
+		return restClient.Get().AbsPath("/readyz").Do(ctx).Error()
+	}, interval, timeout, historySize)
+}
+
+// NewCheckerWithProbe creates a Checker with a custom probe. Non positive values are replaced by the defaults.
+func NewCheckerWithProbe(probe ProbeFunc, interval, timeout time.Duration, historySize int) *Checker {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Checker{
+		probe:    probe,
+		interval: interval,
+		timeout:  timeout,
+		history:  make([]Sample, historySize),
+	}
+}
+
+// Start probes the API server until the context is cancelled. It implements manager.Runnable.
+func (c *Checker) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, c.ProbeOnce, c.interval)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, the checker runs on every instance.
+func (c *Checker) NeedLeaderElection() bool {
+	return false
+}
+
+// ProbeOnce runs a single probe and records its result
+func (c *Checker) ProbeOnce(ctx context.Context) {
+	probeCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	err := c.probe(probeCtx)
+	if err != nil {
+		probeErrorLog.Info(log, "probe", "API server probe failed", "error", err.Error())
+	} else {
+		probeErrorLog.Reset("probe")
+	}
+	c.record(Sample{Time: time.Now(), Err: err})
+}
+
+// LastSuccess returns the time of the last successful probe, or the zero time if there wasn't any.
+func (c *Checker) LastSuccess() time.Time {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lastSuccess
+}
+
+// LastFailure returns the time of the last failed probe, or the zero time if there wasn't any.
+func (c *Checker) LastFailure() time.Time {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lastFailure
+}
+
+// IsConnectedFor returns true if all probes of at least the given duration succeeded.
+// It returns false if the last probe is older than the probe interval plus timeout, e.g. because the checker stopped.
+func (c *Checker) IsConnectedFor(duration time.Duration) bool {
+	return c.isStreakFor(true, duration)
+}
+
+// IsDisconnectedFor returns true if all probes of at least the given duration failed.
+// It returns false if the last probe is older than the probe interval plus timeout, e.g. because the checker stopped.
+func (c *Checker) IsDisconnectedFor(duration time.Duration) bool {
+	return c.isStreakFor(false, duration)
+}
+
+// History returns the recorded samples, oldest first.
+func (c *Checker) History() []Sample {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	samples := make([]Sample, 0, c.count)
+	start := (c.next - c.count + len(c.history)) % len(c.history)
+	for i := 0; i < c.count; i++ {
+		samples = append(samples, c.history[(start+i)%len(c.history)])
+	}
+	return samples
+}
+
+func (c *Checker) isStreakFor(success bool, duration time.Duration) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.count == 0 {
+		return false
+	}
+	latest := c.history[(c.next-1+len(c.history))%len(c.history)]
+	if latest.Success() != success {
+		return false
+	}
+	// probes start one interval after the previous one finished, which took at most the timeout
+	if time.Since(latest.Time) > c.interval+c.timeout {
+		return false
+	}
+	return time.Since(c.streakStart) >= duration
+}
+
+func (c *Checker) record(sample Sample) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.count == 0 || c.history[(c.next-1+len(c.history))%len(c.history)].Success() != sample.Success() {
+		c.streakStart = sample.Time
+	}
+	if sample.Success() {
+		c.lastSuccess = sample.Time
+	} else {
+		c.lastFailure = sample.Time
+	}
+	c.history[c.next] = sample
+	c.next = (c.next + 1) % len(c.history)
+	if c.count < len(c.history) {
+		c.count++
+	}
+}
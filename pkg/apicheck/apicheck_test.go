@@ -0,0 +1,62 @@
+package apicheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIsConnectedFor(t *testing.T) {
+	var probeErr error
+	c := NewCheckerWithProbe(func(_ context.Context) error { return probeErr }, time.Minute, time.Second, 3)
+	if c.IsConnectedFor(0) || c.IsDisconnectedFor(0) {
+		t.Fatalf("expected no result without probes")
+	}
+
+	c.ProbeOnce(context.Background())
+	if !c.IsConnectedFor(0) {
+		t.Errorf("expected connected after a successful probe")
+	}
+	if c.IsConnectedFor(time.Hour) {
+		t.Errorf("expected streak to be shorter than an hour")
+	}
+
+	probeErr = errors.New("unreachable")
+	c.ProbeOnce(context.Background())
+	if c.IsConnectedFor(0) || !c.IsDisconnectedFor(0) {
+		t.Errorf("expected disconnected after a failed probe")
+	}
+	if c.LastSuccess().IsZero() || c.LastFailure().IsZero() {
+		t.Errorf("expected last success and failure to be set")
+	}
+}
+
+func TestStaleHistory(t *testing.T) {
+	c := NewCheckerWithProbe(func(_ context.Context) error { return nil }, time.Minute, time.Second, 3)
+	c.record(Sample{Time: time.Now().Add(-time.Hour)})
+	if c.IsConnectedFor(time.Minute) {
+		t.Errorf("expected a stale history not to count as connected")
+	}
+	c.record(Sample{Time: time.Now()})
+	if !c.IsConnectedFor(time.Minute) {
+		t.Errorf("expected connected after a fresh probe")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	c := NewCheckerWithProbe(func(_ context.Context) error { return nil }, time.Minute, time.Second, 3)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		c.record(Sample{Time: start.Add(time.Duration(i) * time.Second)})
+	}
+	history := c.History()
+	if len(history) != 3 {
+		t.Fatalf("expected 3 samples, got %d", len(history))
+	}
+	for i, sample := range history {
+		if expected := start.Add(time.Duration(i+2) * time.Second); !sample.Time.Equal(expected) {
+			t.Errorf("sample %d: expected %s, got %s", i, expected, sample.Time)
+		}
+	}
+}
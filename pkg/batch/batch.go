@@ -0,0 +1,188 @@
+// Package batch groups unhealthy nodes and hands out batches for rolling remediation.
+package batch
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/medik8s/common/pkg/nodes"
+)
+
+const (
+	// ZoneLabel is the well known topology zone label
+	ZoneLabel = corev1.LabelTopologyZone
+
+	roleControlPlane = "control-plane"
+	roleWorker       = "worker"
+)
+
+// GroupFunc returns the group key of a node
+type GroupFunc func(node *corev1.Node) string
+
+// ByZone groups nodes by their topology zone
+func ByZone() GroupFunc {
+	return ByLabel(ZoneLabel)
+}
+
+// ByRole groups nodes into control plane and worker nodes
+func ByRole() GroupFunc {
+	return func(node *corev1.Node) string {
+		if nodes.IsControlPlane(node) {
+			return roleControlPlane
+		}
+		return roleWorker
+	}
+}
+
+// ByLabel groups nodes by the value of the given label, e.g. a node group or machine pool label.
+// Nodes without the label end up in the group with the empty key.
+func ByLabel(key string) GroupFunc {
+	return func(node *corev1.Node) string {
+		return node.Labels[key]
+	}
+}
+
+// OrderPolicy defines which group is remediated first
+type OrderPolicy string
+
+const (
+	// LargestFirst starts with the group with the most unhealthy nodes
+	LargestFirst OrderPolicy = "LargestFirst"
+	// SmallestFirst starts with the group with the fewest unhealthy nodes
+	SmallestFirst OrderPolicy = "SmallestFirst"
+	// Alphabetical orders groups by their key
+	Alphabetical OrderPolicy = "Alphabetical"
+)
+
+// Group is a set of unhealthy nodes sharing the same group key
+type Group struct {
+	Key   string
+	Nodes []corev1.Node
+}
+
+// Planner computes remediation batches
+type Planner struct {
+	// GroupBy groups the unhealthy nodes, nodes of different groups are never remediated in the same batch.
+	// Defaults to a single group for all nodes.
+	GroupBy GroupFunc
+	// Order defines the order of the groups, defaults to Alphabetical
+	Order OrderPolicy
+	// MinHealthy is the absolute number or percentage of all nodes which needs to be healthy for remediation to continue
+	MinHealthy *intstr.IntOrString
+	// MaxBatchSize is the absolute number or percentage of all nodes which may be remediated concurrently.
+	// Defaults to 1.
+	MaxBatchSize *intstr.IntOrString
+}
+
+// Groups groups and orders the given unhealthy nodes. Nodes inside a group are ordered by name.
+func (p *Planner) Groups(unhealthy []corev1.Node) []Group {
+	groupBy := p.groupBy()
+	groupsByKey := make(map[string]*Group)
+	var groups []*Group
+	for i := range unhealthy {
+		key := groupBy(&unhealthy[i])
+		group, exists := groupsByKey[key]
+		if !exists {
+			group = &Group{Key: key}
+			groupsByKey[key] = group
+			groups = append(groups, group)
+		}
+		group.Nodes = append(group.Nodes, unhealthy[i])
+	}
+
+	result := make([]Group, 0, len(groups))
+	for _, group := range groups {
+		sort.Slice(group.Nodes, func(i, j int) bool { return group.Nodes[i].Name < group.Nodes[j].Name })
+		result = append(result, *group)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		switch p.Order {
+		case LargestFirst:
+			if len(result[i].Nodes) != len(result[j].Nodes) {
+				return len(result[i].Nodes) > len(result[j].Nodes)
+			}
+		case SmallestFirst:
+			if len(result[i].Nodes) != len(result[j].Nodes) {
+				return len(result[i].Nodes) < len(result[j].Nodes)
+			}
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// NextBatch returns the unhealthy nodes which should be remediated next.
+// totalNodes is the number of all nodes covered by the policy, inFlight the nodes currently being remediated,
+// which aren't part of unhealthy anymore.
+// Groups are remediated one after another: while nodes are in flight, the batch is taken from their group, and no
+// other group is started until the in flight nodes are done.
+// An empty batch is returned when the minHealthy constraint isn't met, or when the maximum batch size is already in flight.
+func (p *Planner) NextBatch(totalNodes int, unhealthy []corev1.Node, inFlight []corev1.Node) ([]corev1.Node, error) {
+	healthy := totalNodes - len(unhealthy) - len(inFlight)
+	if p.MinHealthy != nil {
+		minHealthy, err := intstr.GetScaledValueFromIntOrPercent(p.MinHealthy, totalNodes, true)
+		if err != nil {
+			return nil, fmt.Errorf("invalid minHealthy value: %w", err)
+		}
+		if healthy < minHealthy {
+			return nil, nil
+		}
+	}
+
+	maxBatchSize := 1
+	if p.MaxBatchSize != nil {
+		var err error
+		if maxBatchSize, err = intstr.GetScaledValueFromIntOrPercent(p.MaxBatchSize, totalNodes, false); err != nil {
+			return nil, fmt.Errorf("invalid maxBatchSize value: %w", err)
+		}
+		if maxBatchSize < 1 {
+			maxBatchSize = 1
+		}
+	}
+	available := maxBatchSize - len(inFlight)
+	if available <= 0 {
+		return nil, nil
+	}
+
+	groups := p.Groups(unhealthy)
+	if len(groups) == 0 {
+		return nil, nil
+	}
+	next := &groups[0]
+	if len(inFlight) > 0 {
+		if next = p.inFlightGroup(groups, inFlight); next == nil {
+			// the in flight group has no unhealthy nodes left, wait until it is done
+			return nil, nil
+		}
+	}
+	batch := next.Nodes
+	if len(batch) > available {
+		batch = batch[:available]
+	}
+	return batch, nil
+}
+
+// inFlightGroup returns the first of the groups which has nodes in flight, or nil
+func (p *Planner) inFlightGroup(groups []Group, inFlight []corev1.Node) *Group {
+	groupBy := p.groupBy()
+	inFlightKeys := make(map[string]bool, len(inFlight))
+	for i := range inFlight {
+		inFlightKeys[groupBy(&inFlight[i])] = true
+	}
+	for i := range groups {
+		if inFlightKeys[groups[i].Key] {
+			return &groups[i]
+		}
+	}
+	return nil
+}
+
+func (p *Planner) groupBy() GroupFunc {
+	if p.GroupBy == nil {
+		return func(_ *corev1.Node) string { return "" }
+	}
+	return p.GroupBy
+}
@@ -0,0 +1,103 @@
+package batch
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func node(name, zone string) corev1.Node {
+	return corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{ZoneLabel: zone}}}
+}
+
+func names(nodes []corev1.Node) []string {
+	var result []string
+	for _, n := range nodes {
+		result = append(result, n.Name)
+	}
+	return result
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGroups(t *testing.T) {
+	unhealthy := []corev1.Node{node("c", "b"), node("a", "a"), node("d", "b"), node("b", "b")}
+	tests := []struct {
+		name     string
+		order    OrderPolicy
+		expected []string
+	}{
+		{name: "alphabetical", order: Alphabetical, expected: []string{"a", "b"}},
+		{name: "largest first", order: LargestFirst, expected: []string{"b", "a"}},
+		{name: "smallest first", order: SmallestFirst, expected: []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Planner{GroupBy: ByZone(), Order: tt.order}
+			groups := p.Groups(unhealthy)
+			var keys []string
+			for _, group := range groups {
+				keys = append(keys, group.Key)
+			}
+			if !equal(keys, tt.expected) {
+				t.Errorf("expected groups %v, got %v", tt.expected, keys)
+			}
+			for _, group := range groups {
+				if group.Key == "b" && !equal(names(group.Nodes), []string{"b", "c", "d"}) {
+					t.Errorf("expected nodes of group b sorted by name, got %v", names(group.Nodes))
+				}
+			}
+		})
+	}
+}
+
+func TestNextBatch(t *testing.T) {
+	unhealthy := []corev1.Node{node("a", "a"), node("b", "b"), node("c", "b"), node("d", "b")}
+	tests := []struct {
+		name         string
+		totalNodes   int
+		inFlight     []corev1.Node
+		order        OrderPolicy
+		minHealthy   *intstr.IntOrString
+		maxBatchSize *intstr.IntOrString
+		expected     []string
+	}{
+		{name: "default batch size of 1", totalNodes: 10, expected: []string{"a"}},
+		{name: "batch limited to one group", totalNodes: 10, maxBatchSize: intOrStr(intstr.FromInt(3)), order: LargestFirst, expected: []string{"b", "c", "d"}},
+		{name: "in flight reduces batch", totalNodes: 10, inFlight: []corev1.Node{node("e", "b")}, maxBatchSize: intOrStr(intstr.FromInt(2)), order: LargestFirst, expected: []string{"b"}},
+		{name: "max batch in flight", totalNodes: 10, inFlight: []corev1.Node{node("e", "b"), node("f", "b")}, maxBatchSize: intOrStr(intstr.FromInt(2)), expected: nil},
+		{name: "in flight group is kept", totalNodes: 10, inFlight: []corev1.Node{node("e", "a")}, maxBatchSize: intOrStr(intstr.FromInt(3)), order: LargestFirst, expected: []string{"a"}},
+		{name: "in flight group drained", totalNodes: 10, inFlight: []corev1.Node{node("e", "c")}, maxBatchSize: intOrStr(intstr.FromInt(3)), expected: nil},
+		{name: "percentage batch size", totalNodes: 10, maxBatchSize: intOrStr(intstr.FromString("20%")), order: LargestFirst, expected: []string{"b", "c"}},
+		{name: "min healthy not met", totalNodes: 10, minHealthy: intOrStr(intstr.FromInt(7)), expected: nil},
+		{name: "min healthy met", totalNodes: 10, minHealthy: intOrStr(intstr.FromString("60%")), expected: []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Planner{GroupBy: ByZone(), Order: tt.order, MinHealthy: tt.minHealthy, MaxBatchSize: tt.maxBatchSize}
+			batch, err := p.NextBatch(tt.totalNodes, unhealthy, tt.inFlight)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !equal(names(batch), tt.expected) {
+				t.Errorf("expected batch %v, got %v", tt.expected, names(batch))
+			}
+		})
+	}
+}
+
+func intOrStr(value intstr.IntOrString) *intstr.IntOrString {
+	return &value
+}
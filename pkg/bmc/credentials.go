@@ -0,0 +1,192 @@
+// Package bmc contains helpers for Secrets holding BMC / IPMI credentials.
+// The Secret format is shared between fence agent based remediation (FAR) and metal3 based flows.
+package bmc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AddressKey is the Secret data key of the BMC address, either a host[:port] or a URL
+	AddressKey = "address"
+	// UsernameKey is the Secret data key of the BMC username
+	UsernameKey = "username"
+	// PasswordKey is the Secret data key of the BMC password
+	PasswordKey = "password"
+	// InsecureKey is the optional Secret data key which disables TLS verification when set to "true"
+	InsecureKey = "insecure"
+
+	redacted = "<redacted>"
+)
+
+// Credentials are the decoded content of a BMC credentials Secret
+type Credentials struct {
+	Address  string
+	Username string
+	Password string
+	Insecure bool
+}
+
+// GetCredentials reads the Secret identified by key and returns its validated credentials.
+func GetCredentials(ctx context.Context, reader client.Reader, key client.ObjectKey) (*Credentials, error) {
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, key, secret); err != nil {
+		return nil, fmt.Errorf("failed to get BMC credentials secret %s: %w", key, err)
+	}
+	return FromSecret(secret)
+}
+
+// FromSecret decodes and validates the credentials in the given Secret.
+func FromSecret(secret *corev1.Secret) (*Credentials, error) {
+	creds := &Credentials{
+		Address:  strings.TrimSpace(string(secret.Data[AddressKey])),
+		Username: string(secret.Data[UsernameKey]),
+		Password: string(secret.Data[PasswordKey]),
+	}
+	if raw, exists := secret.Data[InsecureKey]; exists {
+		insecure, err := strconv.ParseBool(strings.TrimSpace(string(raw)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %q in BMC credentials secret %s/%s: %w", InsecureKey, secret.Namespace, secret.Name, err)
+		}
+		creds.Insecure = insecure
+	}
+	if err := creds.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid BMC credentials secret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+	return creds, nil
+}
+
+// Validate checks that all mandatory fields are set, that the address is parsable and that no field contains line
+// breaks or NUL characters, which would allow injecting options into the fence agent stdin.
+func (c *Credentials) Validate() error {
+	var missing []string
+	if c.Address == "" {
+		missing = append(missing, AddressKey)
+	}
+	if c.Username == "" {
+		missing = append(missing, UsernameKey)
+	}
+	if c.Password == "" {
+		missing = append(missing, PasswordKey)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing keys: %s", strings.Join(missing, ", "))
+	}
+	var invalid []string
+	for key, value := range map[string]string{AddressKey: c.Address, UsernameKey: c.Username, PasswordKey: c.Password} {
+		if strings.ContainsAny(value, "\r\n\x00") {
+			invalid = append(invalid, key)
+		}
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return fmt.Errorf("line breaks or NUL characters in keys: %s", strings.Join(invalid, ", "))
+	}
+	if _, _, err := c.HostPort(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// HostPort returns the host and the optional port of the address. Both plain host[:port] and URL addresses,
+// like the ones used by metal3 (e.g. ipmi://192.168.1.1:623), are supported.
+func (c *Credentials) HostPort() (host string, port string, err error) {
+	address := c.Address
+	if strings.Contains(address, "://") {
+		u, err := url.Parse(address)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid BMC address %q: %w", address, err)
+		}
+		address = u.Host
+	}
+	if address == "" {
+		return "", "", fmt.Errorf("invalid BMC address %q: missing host", c.Address)
+	}
+	host, port, err = net.SplitHostPort(address)
+	if err != nil {
+		// no port
+		return strings.Trim(address, "[]"), "", nil
+	}
+	return host, port, nil
+}
+
+// String returns a representation which is safe for logging. It has a value receiver, so that formatting a
+// Credentials value doesn't print the password either.
+func (c Credentials) String() string {
+	return fmt.Sprintf("{Address:%s Username:%s Password:%s Insecure:%t}", c.Address, c.Username, redacted, c.Insecure)
+}
+
+// Redacted returns a copy with the password replaced, for logging or events.
+func (c *Credentials) Redacted() Credentials {
+	redactedCopy := *c
+	redactedCopy.Password = redacted
+	return redactedCopy
+}
+
+// ToSecret returns a Secret in the shared format, containing the credentials.
+func (c *Credentials) ToSecret(namespace, name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			AddressKey:  []byte(c.Address),
+			UsernameKey: []byte(c.Username),
+			PasswordKey: []byte(c.Password),
+			InsecureKey: []byte(strconv.FormatBool(c.Insecure)),
+		},
+	}
+}
+
+// ToMetal3Secret returns a Secret as referenced by the credentialsName of a metal3 BareMetalHost.
+// metal3 keeps the address and TLS verification in the BareMetalHost spec, so only username and password are included.
+func (c *Credentials) ToMetal3Secret(namespace, name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			UsernameKey: []byte(c.Username),
+			PasswordKey: []byte(c.Password),
+		},
+	}
+}
+
+// FenceAgentStdin returns the connection parameters in the stdin format of fence agents (e.g. fence_ipmilan),
+// one name=value pair per line. Fence agents read their options from stdin when started without arguments, which
+// keeps the password out of the process list. Note that the result contains the password, so it must not be logged.
+func (c *Credentials) FenceAgentStdin() ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	host, port, err := c.HostPort()
+	if err != nil {
+		return nil, err
+	}
+	lines := []string{
+		"ip=" + host,
+		"username=" + c.Username,
+		"password=" + c.Password,
+	}
+	if port != "" {
+		lines = append(lines, "ipport="+port)
+	}
+	if c.Insecure {
+		lines = append(lines, "ssl_insecure=1")
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
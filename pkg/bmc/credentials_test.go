@@ -0,0 +1,112 @@
+package bmc
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestFromSecret(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     map[string]string
+		valid    bool
+		host     string
+		port     string
+		insecure bool
+	}{
+		{name: "host and port", data: map[string]string{"address": "10.0.0.1:623", "username": "u", "password": "p"}, valid: true, host: "10.0.0.1", port: "623"},
+		{name: "host only", data: map[string]string{"address": " bmc.example.com ", "username": "u", "password": "p"}, valid: true, host: "bmc.example.com"},
+		{name: "URL", data: map[string]string{"address": "ipmi://192.168.1.1:623", "username": "u", "password": "p"}, valid: true, host: "192.168.1.1", port: "623"},
+		{name: "IPv6", data: map[string]string{"address": "[fd00::1]:623", "username": "u", "password": "p"}, valid: true, host: "fd00::1", port: "623"},
+		{name: "insecure", data: map[string]string{"address": "h", "username": "u", "password": "p", "insecure": "true"}, valid: true, host: "h", insecure: true},
+		{name: "invalid insecure", data: map[string]string{"address": "h", "username": "u", "password": "p", "insecure": "maybe"}},
+		{name: "missing password", data: map[string]string{"address": "h", "username": "u"}},
+		{name: "missing address", data: map[string]string{"username": "u", "password": "p"}},
+		{name: "newline in password", data: map[string]string{"address": "h", "username": "u", "password": "p\nip=10.0.0.2"}},
+		{name: "carriage return in username", data: map[string]string{"address": "h", "username": "u\raction=off", "password": "p"}},
+		{name: "newline in address", data: map[string]string{"address": "h\nssl_insecure=1", "username": "u", "password": "p"}},
+		{name: "NUL in password", data: map[string]string{"address": "h", "username": "u", "password": "p\x00"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{Data: map[string][]byte{}}
+			for key, value := range tt.data {
+				secret.Data[key] = []byte(value)
+			}
+			creds, err := FromSecret(secret)
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			host, port, _ := creds.HostPort()
+			if host != tt.host || port != tt.port {
+				t.Errorf("expected %s %s, got %s %s", tt.host, tt.port, host, port)
+			}
+			if creds.Insecure != tt.insecure {
+				t.Errorf("expected insecure %t", tt.insecure)
+			}
+		})
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	creds := &Credentials{Address: "h", Username: "u", Password: "s3cret"}
+	for _, format := range []string{"%v", "%+v", "%s"} {
+		for _, value := range []interface{}{creds, *creds} {
+			if formatted := fmt.Sprintf(format, value); strings.Contains(formatted, "s3cret") {
+				t.Errorf("%s of %T contains the password: %s", format, value, formatted)
+			}
+		}
+	}
+	if redacted := creds.Redacted(); redacted.Password == "s3cret" || creds.Password != "s3cret" {
+		t.Errorf("Redacted() must only redact the copy")
+	}
+	if roundTrip, err := FromSecret(creds.ToSecret("ns", "name")); err != nil || *roundTrip != *creds {
+		t.Errorf("ToSecret round trip failed: %v, %v", roundTrip, err)
+	}
+}
+
+func TestFenceAgentStdin(t *testing.T) {
+	tests := []struct {
+		name     string
+		creds    Credentials
+		expected string
+	}{
+		{
+			name:     "host only",
+			creds:    Credentials{Address: "10.0.0.1", Username: "u", Password: "p"},
+			expected: "ip=10.0.0.1\nusername=u\npassword=p\n",
+		},
+		{
+			name:     "port and insecure",
+			creds:    Credentials{Address: "ipmi://bmc:623", Username: "u", Password: "p", Insecure: true},
+			expected: "ip=bmc\nusername=u\npassword=p\nipport=623\nssl_insecure=1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdin, err := tt.creds.FenceAgentStdin()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(stdin) != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, string(stdin))
+			}
+		})
+	}
+}
+
+func TestFenceAgentStdinRejectsInjection(t *testing.T) {
+	creds := Credentials{Address: "10.0.0.1", Username: "u", Password: "p\naction=off"}
+	if stdin, err := creds.FenceAgentStdin(); err == nil {
+		t.Errorf("expected error, got %q", string(stdin))
+	}
+}
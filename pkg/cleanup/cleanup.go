@@ -0,0 +1,210 @@
+// Package cleanup implements the teardown sequence for nodes which are gone for good.
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	coordv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/common/pkg/tracing"
+)
+
+// Step is a single step of the cleanup sequence
+type Step string
+
+const (
+	// StepRemoveAnnotations removes medik8s annotations from the Node
+	StepRemoveAnnotations Step = "RemoveAnnotations"
+	// StepDeleteLeases deletes the medik8s leases of the Node
+	StepDeleteLeases Step = "DeleteLeases"
+	// StepForceDeletePods force deletes all pods scheduled on the Node
+	StepForceDeletePods Step = "ForceDeletePods"
+	// StepDeleteVolumeAttachments deletes the VolumeAttachments of the Node
+	StepDeleteVolumeAttachments Step = "DeleteVolumeAttachments"
+	// StepDeleteNode deletes the Node object
+	StepDeleteNode Step = "DeleteNode"
+
+	// Medik8sDomain is the domain of annotations which are removed from the Node, including its subdomains
+	Medik8sDomain = "medik8s.io"
+)
+
+var log = ctrl.Log.WithName("cleanup")
+
+// StepResult is the outcome of a single step
+type StepResult struct {
+	Step Step
+	// Deleted is the number of deleted or modified objects
+	Deleted int
+	// Err is set when the step failed
+	Err error
+	// Skipped is set when the step didn't run
+	Skipped bool
+}
+
+// Options configure the cleanup
+type Options struct {
+	// LeaseNamespace is the namespace of the medik8s leases. The lease step is skipped when empty.
+	LeaseNamespace string
+	// LeaseNames returns the candidate lease names of a node. Defaults to "node-<name>".
+	LeaseNames func(nodeName string) []string
+}
+
+// CleanupNode runs the full teardown sequence for a node that is gone for good: removing medik8s annotations,
+// deleting medik8s leases, force deleting pods, deleting VolumeAttachments and finally deleting the Node.
+// All steps run even if previous ones failed, except for deleting the Node, which is skipped then so that the cleanup
+// can be retried. It returns the result of every step and an aggregated error.
+// Pods are listed by the spec.nodeName field, so a cached client needs a matching field index.
+func CleanupNode(ctx context.Context, cl client.Client, nodeName string, opts Options) (results []StepResult, err error) {
+	ctx, span := tracing.Start(ctx, "cleanup.CleanupNode", attribute.String("node", nodeName))
+	defer func() { tracing.End(span, err) }()
+
+	steps := []struct {
+		step Step
+		run  func() (int, error)
+	}{
+		{StepRemoveAnnotations, func() (int, error) { return removeAnnotations(ctx, cl, nodeName) }},
+		{StepDeleteLeases, func() (int, error) { return deleteLeases(ctx, cl, nodeName, opts) }},
+		{StepForceDeletePods, func() (int, error) { return forceDeletePods(ctx, cl, nodeName) }},
+		{StepDeleteVolumeAttachments, func() (int, error) { return deleteVolumeAttachments(ctx, cl, nodeName) }},
+		{StepDeleteNode, func() (int, error) { return deleteNode(ctx, cl, nodeName) }},
+	}
+
+	results = make([]StepResult, 0, len(steps))
+	var errs []error
+	for _, s := range steps {
+		if s.step == StepDeleteNode && len(errs) > 0 {
+			log.Info("skipping node deletion, previous cleanup steps failed", "node", nodeName)
+			results = append(results, StepResult{Step: s.step, Skipped: true})
+			continue
+		}
+		_, stepSpan := tracing.Start(ctx, "cleanup."+string(s.step), attribute.String("node", nodeName))
+		deleted, err := s.run()
+		stepSpan.SetAttributes(attribute.Int("deleted", deleted))
+		tracing.End(stepSpan, err)
+		if err != nil {
+			log.Error(err, "node cleanup step failed", "node", nodeName, "step", s.step)
+			errs = append(errs, fmt.Errorf("%s: %w", s.step, err))
+		} else {
+			log.Info("node cleanup step succeeded", "node", nodeName, "step", s.step, "deleted", deleted)
+		}
+		results = append(results, StepResult{Step: s.step, Deleted: deleted, Err: err})
+	}
+	return results, utilerrors.NewAggregate(errs)
+}
+
+func removeAnnotations(ctx context.Context, cl client.Client, nodeName string) (int, error) {
+	node := &corev1.Node{}
+	if err := cl.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+		return 0, client.IgnoreNotFound(err)
+	}
+	patch := client.MergeFrom(node.DeepCopy())
+	removed := 0
+	for key := range node.Annotations {
+		if isMedik8sAnnotation(key) {
+			delete(node.Annotations, key)
+			removed++
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	if err := cl.Patch(ctx, node, patch); err != nil {
+		return 0, client.IgnoreNotFound(err)
+	}
+	return removed, nil
+}
+
+func deleteLeases(ctx context.Context, cl client.Client, nodeName string, opts Options) (int, error) {
+	if opts.LeaseNamespace == "" {
+		return 0, nil
+	}
+	leaseNames := opts.LeaseNames
+	if leaseNames == nil {
+		leaseNames = defaultLeaseNames
+	}
+	deleted := 0
+	for _, name := range leaseNames(nodeName) {
+		lease := &coordv1.Lease{}
+		lease.Namespace = opts.LeaseNamespace
+		lease.Name = name
+		if err := cl.Delete(ctx, lease); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+func forceDeletePods(ctx context.Context, cl client.Client, nodeName string) (int, error) {
+	pods := &corev1.PodList{}
+	if err := cl.List(ctx, pods, client.MatchingFields{"spec.nodeName": nodeName}); err != nil {
+		return 0, err
+	}
+	deleted := 0
+	var errs []error
+	for i := range pods.Items {
+		if err := cl.Delete(ctx, &pods.Items[i], client.GracePeriodSeconds(0)); err != nil {
+			if !apierrors.IsNotFound(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		deleted++
+	}
+	return deleted, utilerrors.NewAggregate(errs)
+}
+
+func deleteVolumeAttachments(ctx context.Context, cl client.Client, nodeName string) (int, error) {
+	vas := &storagev1.VolumeAttachmentList{}
+	if err := cl.List(ctx, vas); err != nil {
+		return 0, err
+	}
+	deleted := 0
+	var errs []error
+	for i := range vas.Items {
+		if vas.Items[i].Spec.NodeName != nodeName {
+			continue
+		}
+		if err := cl.Delete(ctx, &vas.Items[i]); err != nil {
+			if !apierrors.IsNotFound(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		deleted++
+	}
+	return deleted, utilerrors.NewAggregate(errs)
+}
+
+func deleteNode(ctx context.Context, cl client.Client, nodeName string) (int, error) {
+	node := &corev1.Node{}
+	node.Name = nodeName
+	if err := cl.Delete(ctx, node); err != nil {
+		return 0, client.IgnoreNotFound(err)
+	}
+	return 1, nil
+}
+
+func isMedik8sAnnotation(key string) bool {
+	prefix, _, found := strings.Cut(key, "/")
+	if !found {
+		return false
+	}
+	return prefix == Medik8sDomain || strings.HasSuffix(prefix, "."+Medik8sDomain)
+}
+
+func defaultLeaseNames(nodeName string) []string {
+	return []string{"node-" + nodeName}
+}
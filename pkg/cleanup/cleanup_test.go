@@ -0,0 +1,71 @@
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestCleanupNode(t *testing.T) {
+	tests := []struct {
+		name        string
+		failLeases  bool
+		nodeDeleted bool
+	}{
+		{name: "all steps succeed", nodeDeleted: true},
+		{name: "failed step keeps node", failLeases: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cl := fake.NewClientBuilder().
+				WithObjects(
+					&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n", Annotations: map[string]string{"remediation.medik8s.io/x": "y", "other": "z"}}},
+					&coordv1.Lease{ObjectMeta: metav1.ObjectMeta{Namespace: "leases", Name: "node-n"}},
+					&coordv1.Lease{ObjectMeta: metav1.ObjectMeta{Namespace: "leases", Name: "n"}},
+					&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"}, Spec: corev1.PodSpec{NodeName: "n"}},
+					&storagev1.VolumeAttachment{ObjectMeta: metav1.ObjectMeta{Name: "va"}, Spec: storagev1.VolumeAttachmentSpec{NodeName: "n"}},
+				).
+				WithIndex(&corev1.Pod{}, "spec.nodeName", func(obj client.Object) []string {
+					return []string{obj.(*corev1.Pod).Spec.NodeName}
+				}).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Delete: func(ctx context.Context, cl client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+						if _, isLease := obj.(*coordv1.Lease); isLease && tt.failLeases {
+							return errors.New("injected")
+						}
+						return cl.Delete(ctx, obj, opts...)
+					},
+				}).
+				Build()
+
+			results, err := CleanupNode(ctx, cl, "n", Options{LeaseNamespace: "leases"})
+			if tt.failLeases != (err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(results) != 5 {
+				t.Fatalf("expected 5 results, got %d", len(results))
+			}
+			if last := results[4]; last.Step != StepDeleteNode || last.Skipped == tt.nodeDeleted {
+				t.Errorf("unexpected node deletion result %+v", last)
+			}
+
+			err = cl.Get(ctx, client.ObjectKey{Name: "n"}, &corev1.Node{})
+			if deleted := apierrors.IsNotFound(err); deleted != tt.nodeDeleted {
+				t.Errorf("expected node deleted %t, got error %v", tt.nodeDeleted, err)
+			}
+			if err := cl.Get(ctx, client.ObjectKey{Namespace: "leases", Name: "n"}, &coordv1.Lease{}); err != nil {
+				t.Errorf("expected lease named after the node to be kept, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	awsScheme = "aws"
+
+	// AWSAccessKeyIDKey is the Secret key of the AWS access key ID
+	AWSAccessKeyIDKey = "aws_access_key_id"
+	// AWSSecretAccessKeyKey is the Secret key of the AWS secret access key
+	AWSSecretAccessKeyKey = "aws_secret_access_key"
+	// AWSRegionKey is the optional Secret key of the AWS region. Without it the region is read from the OpenShift
+	// Infrastructure config.
+	AWSRegionKey = "aws_region"
+
+	awsInstanceNotFound = "InvalidInstanceID.NotFound"
+)
+
+var infrastructureGVK = schema.GroupVersionKind{Group: "config.openshift.io", Version: "v1", Kind: "Infrastructure"}
+
+type awsProvider struct {
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+}
+
+func newAWSProvider(ctx context.Context, reader client.Reader, secret *corev1.Secret) (Provider, error) {
+	accessKeyID, err := secretValue(secret, AWSAccessKeyIDKey)
+	if err != nil {
+		return nil, err
+	}
+	secretAccessKey, err := secretValue(secret, AWSSecretAccessKeyKey)
+	if err != nil {
+		return nil, err
+	}
+	region := strings.TrimSpace(string(secret.Data[AWSRegionKey]))
+	if region == "" {
+		if region, err = getInfrastructureAWSRegion(ctx, reader); err != nil {
+			return nil, err
+		}
+	}
+	return &awsProvider{
+		region:          region,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+	}, nil
+}
+
+// InstanceState implements Provider. Provider IDs look like aws:///us-east-1a/i-0123456789abcdef0
+func (p *awsProvider) InstanceState(ctx context.Context, providerID string) (InstanceState, error) {
+	instanceID, err := parseAWSProviderID(providerID)
+	if err != nil {
+		return StateUnknown, err
+	}
+	ec2Client := ec2.NewFromConfig(aws.Config{
+		Region:      p.region,
+		Credentials: credentials.NewStaticCredentialsProvider(p.accessKeyID, p.secretAccessKey, ""),
+	})
+	output, err := ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{InstanceIds: []string{instanceID}})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == awsInstanceNotFound {
+			return StateTerminated, nil
+		}
+		return StateUnknown, fmt.Errorf("failed to describe AWS instance %s: %w", instanceID, err)
+	}
+	for _, reservation := range output.Reservations {
+		for _, instance := range reservation.Instances {
+			if instance.State == nil {
+				continue
+			}
+			switch instance.State.Name {
+			case ec2types.InstanceStateNamePending, ec2types.InstanceStateNameRunning:
+				return StateRunning, nil
+			case ec2types.InstanceStateNameStopping, ec2types.InstanceStateNameShuttingDown:
+				return StateStopping, nil
+			case ec2types.InstanceStateNameStopped:
+				return StateStopped, nil
+			case ec2types.InstanceStateNameTerminated:
+				return StateTerminated, nil
+			}
+		}
+	}
+	return StateUnknown, nil
+}
+
+// parseAWSProviderID returns the instance ID of the provider ID. The region can't be derived from the zone, because
+// zones of Local and Wavelength Zones (e.g. us-east-1-bos-1a) don't follow the <region><letter> pattern.
+func parseAWSProviderID(providerID string) (instanceID string, err error) {
+	rest, found := strings.CutPrefix(providerID, awsScheme+"://")
+	if !found {
+		return "", fmt.Errorf("invalid AWS provider ID %q", providerID)
+	}
+	parts := strings.Split(rest, "/")
+	instanceID = parts[len(parts)-1]
+	if len(parts) < 3 || parts[len(parts)-2] == "" || !strings.HasPrefix(instanceID, "i-") {
+		return "", fmt.Errorf("invalid AWS provider ID %q", providerID)
+	}
+	return instanceID, nil
+}
+
+// getInfrastructureAWSRegion returns the region of the OpenShift Infrastructure config
+func getInfrastructureAWSRegion(ctx context.Context, reader client.Reader) (string, error) {
+	infrastructure := &unstructured.Unstructured{}
+	infrastructure.SetGroupVersionKind(infrastructureGVK)
+	if err := reader.Get(ctx, client.ObjectKey{Name: "cluster"}, infrastructure); err != nil {
+		if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+			return "", fmt.Errorf("no AWS region configured, set %s in the cloud credentials secret", AWSRegionKey)
+		}
+		return "", fmt.Errorf("failed to get OpenShift infrastructure config: %w", err)
+	}
+	region, _, _ := unstructured.NestedString(infrastructure.Object, "status", "platformStatus", "aws", "region")
+	if region == "" {
+		return "", fmt.Errorf("no AWS region configured, set %s in the cloud credentials secret", AWSRegionKey)
+	}
+	return region, nil
+}
@@ -0,0 +1,87 @@
+package cloud
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParseAWSProviderID(t *testing.T) {
+	tests := []struct {
+		providerID string
+		instanceID string
+		valid      bool
+	}{
+		{providerID: "aws:///us-east-1a/i-0123456789abcdef0", instanceID: "i-0123456789abcdef0", valid: true},
+		{providerID: "aws:///us-east-1-bos-1a/i-0123456789abcdef0", instanceID: "i-0123456789abcdef0", valid: true},
+		{providerID: "aws:///us-east-1-wl1-bos-wlz-1/i-0abc", instanceID: "i-0abc", valid: true},
+		{providerID: "aws:///i-0123456789abcdef0"},
+		{providerID: "aws:///us-east-1a/"},
+		{providerID: "aws:////i-0123456789abcdef0"},
+		{providerID: "gce:///us-east-1a/i-0123456789abcdef0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.providerID, func(t *testing.T) {
+			instanceID, err := parseAWSProviderID(tt.providerID)
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("expected error, got %s", instanceID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if instanceID != tt.instanceID {
+				t.Errorf("expected %s, got %s", tt.instanceID, instanceID)
+			}
+		})
+	}
+}
+
+func TestAWSRegion(t *testing.T) {
+	infrastructure := &unstructured.Unstructured{}
+	infrastructure.SetGroupVersionKind(infrastructureGVK)
+	infrastructure.SetName("cluster")
+	_ = unstructured.SetNestedField(infrastructure.Object, "us-west-2", "status", "platformStatus", "aws", "region")
+
+	tests := []struct {
+		name     string
+		region   string
+		objects  []client.Object
+		expected string
+	}{
+		{name: "region of the secret", region: "eu-west-1", objects: []client.Object{infrastructure}, expected: "eu-west-1"},
+		{name: "region of the infrastructure", objects: []client.Object{infrastructure}, expected: "us-west-2"},
+		{name: "no region"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{Data: map[string][]byte{
+				AWSAccessKeyIDKey:     []byte("id"),
+				AWSSecretAccessKeyKey: []byte("key"),
+			}}
+			if tt.region != "" {
+				secret.Data[AWSRegionKey] = []byte(tt.region)
+			}
+			cl := fake.NewClientBuilder().WithObjects(tt.objects...).Build()
+			provider, err := newAWSProvider(context.Background(), cl, secret)
+			if tt.expected == "" {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if region := provider.(*awsProvider).region; region != tt.expected {
+				t.Errorf("expected region %s, got %s", tt.expected, region)
+			}
+		})
+	}
+}
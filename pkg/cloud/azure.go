@@ -0,0 +1,149 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v5"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	azureScheme = "azure"
+
+	// AzureClientIDKey is the Secret key of the Azure client ID
+	AzureClientIDKey = "azure_client_id"
+	// AzureClientSecretKey is the Secret key of the Azure client secret
+	AzureClientSecretKey = "azure_client_secret"
+	// AzureTenantIDKey is the Secret key of the Azure tenant ID
+	AzureTenantIDKey = "azure_tenant_id"
+
+	azurePowerStatePrefix = "PowerState/"
+)
+
+type azureProvider struct {
+	credential azcore.TokenCredential
+}
+
+func newAzureProvider(secret *corev1.Secret) (Provider, error) {
+	clientID, err := secretValue(secret, AzureClientIDKey)
+	if err != nil {
+		return nil, err
+	}
+	clientSecret, err := secretValue(secret, AzureClientSecretKey)
+	if err != nil {
+		return nil, err
+	}
+	tenantID, err := secretValue(secret, AzureTenantIDKey)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := azidentity.NewClientSecretCredential(tenantID, clientID, clientSecret, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure credential: %w", err)
+	}
+	return &azureProvider{credential: credential}, nil
+}
+
+// azureInstance identifies a VM, or a VM of a scale set
+type azureInstance struct {
+	subscription  string
+	resourceGroup string
+	// scaleSet is empty for VMs which aren't part of a scale set
+	scaleSet string
+	// name is the VM name, or the instance ID within the scale set
+	name string
+}
+
+// InstanceState implements Provider. Provider IDs look like
+// azure:///subscriptions/<subscription>/resourceGroups/<group>/providers/Microsoft.Compute/virtualMachines/<name>, or
+// .../Microsoft.Compute/virtualMachineScaleSets/<scale set>/virtualMachines/<instance ID> for scale set VMs.
+func (p *azureProvider) InstanceState(ctx context.Context, providerID string) (InstanceState, error) {
+	instance, err := parseAzureProviderID(providerID)
+	if err != nil {
+		return StateUnknown, err
+	}
+	statuses, err := p.getStatuses(ctx, instance)
+	if err != nil {
+		var responseErr *azcore.ResponseError
+		if errors.As(err, &responseErr) && responseErr.StatusCode == http.StatusNotFound {
+			return StateTerminated, nil
+		}
+		return StateUnknown, fmt.Errorf("failed to get Azure VM instance view of %s: %w", providerID, err)
+	}
+	for _, status := range statuses {
+		if status == nil || status.Code == nil || !strings.HasPrefix(*status.Code, azurePowerStatePrefix) {
+			continue
+		}
+		switch strings.TrimPrefix(*status.Code, azurePowerStatePrefix) {
+		case "running", "starting":
+			return StateRunning, nil
+		case "stopping", "deallocating":
+			return StateStopping, nil
+		case "stopped", "deallocated":
+			return StateStopped, nil
+		}
+	}
+	return StateUnknown, nil
+}
+
+func (p *azureProvider) getStatuses(ctx context.Context, instance azureInstance) ([]*armcompute.InstanceViewStatus, error) {
+	if instance.scaleSet != "" {
+		vmssClient, err := armcompute.NewVirtualMachineScaleSetVMsClient(instance.subscription, p.credential, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure scale set VM client: %w", err)
+		}
+		response, err := vmssClient.GetInstanceView(ctx, instance.resourceGroup, instance.scaleSet, instance.name, nil)
+		if err != nil {
+			return nil, err
+		}
+		return response.Statuses, nil
+	}
+	vmClient, err := armcompute.NewVirtualMachinesClient(instance.subscription, p.credential, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure VM client: %w", err)
+	}
+	response, err := vmClient.InstanceView(ctx, instance.resourceGroup, instance.name, nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.Statuses, nil
+}
+
+// parseAzureProviderID only accepts the exact VM and scale set VM shapes. Any other shape is an error, because
+// querying the wrong resource returns NotFound, which would be reported as StateTerminated.
+func parseAzureProviderID(providerID string) (azureInstance, error) {
+	invalid := fmt.Errorf("invalid Azure provider ID %q", providerID)
+	rest, found := strings.CutPrefix(providerID, azureScheme+"://")
+	if !found {
+		return azureInstance{}, invalid
+	}
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+	for _, part := range parts {
+		if part == "" {
+			return azureInstance{}, invalid
+		}
+	}
+	if len(parts) != 8 && len(parts) != 10 ||
+		!strings.EqualFold(parts[0], "subscriptions") ||
+		!strings.EqualFold(parts[2], "resourceGroups") ||
+		!strings.EqualFold(parts[4], "providers") ||
+		!strings.EqualFold(parts[5], "Microsoft.Compute") {
+		return azureInstance{}, invalid
+	}
+	instance := azureInstance{subscription: parts[1], resourceGroup: parts[3]}
+	switch {
+	case len(parts) == 8 && strings.EqualFold(parts[6], "virtualMachines"):
+		instance.name = parts[7]
+	case len(parts) == 10 && strings.EqualFold(parts[6], "virtualMachineScaleSets") && strings.EqualFold(parts[8], "virtualMachines"):
+		instance.scaleSet, instance.name = parts[7], parts[9]
+	default:
+		return azureInstance{}, invalid
+	}
+	return instance, nil
+}
@@ -0,0 +1,50 @@
+package cloud
+
+import "testing"
+
+func TestParseAzureProviderID(t *testing.T) {
+	tests := []struct {
+		providerID string
+		expected   azureInstance
+		valid      bool
+	}{
+		{
+			providerID: "azure:///subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/master-0",
+			expected:   azureInstance{subscription: "sub", resourceGroup: "rg", name: "master-0"},
+			valid:      true,
+		},
+		{
+			providerID: "azure:///subscriptions/sub/resourcegroups/rg/providers/microsoft.compute/virtualmachines/worker",
+			expected:   azureInstance{subscription: "sub", resourceGroup: "rg", name: "worker"},
+			valid:      true,
+		},
+		{
+			providerID: "azure:///subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachineScaleSets/ss/virtualMachines/0",
+			expected:   azureInstance{subscription: "sub", resourceGroup: "rg", scaleSet: "ss", name: "0"},
+			valid:      true,
+		},
+		{providerID: "azure:///subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachineScaleSets/ss"},
+		{providerID: "azure:///subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/availabilitySets/as/virtualMachines/0"},
+		{providerID: "azure:///subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualMachines/vm"},
+		{providerID: "azure:///subscriptions/sub/resourceGroups//providers/Microsoft.Compute/virtualMachines/vm"},
+		{providerID: "azure:///subscriptions/sub/virtualMachines/vm"},
+		{providerID: "aws:///subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.providerID, func(t *testing.T) {
+			instance, err := parseAzureProviderID(tt.providerID)
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("expected error, got %+v", instance)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if instance != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, instance)
+			}
+		})
+	}
+}
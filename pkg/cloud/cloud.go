@@ -0,0 +1,74 @@
+// Package cloud answers whether the cloud instance backing a node is running, stopped or terminated,
+// so that operators can distinguish a crashed node from a deliberately stopped instance before fencing.
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// InstanceState is the provider independent state of an instance
+type InstanceState string
+
+const (
+	// StateRunning means the instance is running, or starting
+	StateRunning InstanceState = "Running"
+	// StateStopping means the instance is shutting down
+	StateStopping InstanceState = "Stopping"
+	// StateStopped means the instance was stopped, or deallocated
+	StateStopped InstanceState = "Stopped"
+	// StateTerminated means the instance was deleted
+	StateTerminated InstanceState = "Terminated"
+	// StateUnknown means the provider reported an unknown state
+	StateUnknown InstanceState = "Unknown"
+)
+
+// Provider queries instance states of a cloud provider
+type Provider interface {
+	// InstanceState returns the state of the instance with the given provider ID
+	InstanceState(ctx context.Context, providerID string) (InstanceState, error)
+}
+
+// NewProviderForNode returns the provider matching the node's provider ID, with credentials read from the given
+// Secret. The Secret uses the format of the OpenShift cloud credential Secrets of the respective provider. On AWS
+// the region is read from the optional AWSRegionKey of the Secret, or else from the OpenShift Infrastructure config.
+func NewProviderForNode(ctx context.Context, reader client.Reader, node *corev1.Node, credentialsSecret client.ObjectKey) (Provider, error) {
+	if node.Spec.ProviderID == "" {
+		return nil, fmt.Errorf("node %s has no provider ID", node.Name)
+	}
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, credentialsSecret, secret); err != nil {
+		return nil, fmt.Errorf("failed to get cloud credentials secret %s: %w", credentialsSecret, err)
+	}
+
+	scheme, _, _ := strings.Cut(node.Spec.ProviderID, "://")
+	switch scheme {
+	case awsScheme:
+		return newAWSProvider(ctx, reader, secret)
+	case azureScheme:
+		return newAzureProvider(secret)
+	default:
+		return nil, fmt.Errorf("unsupported cloud provider %q of node %s", scheme, node.Name)
+	}
+}
+
+// GetNodeInstanceState is a shortcut for creating the node's provider and querying its instance state
+func GetNodeInstanceState(ctx context.Context, reader client.Reader, node *corev1.Node, credentialsSecret client.ObjectKey) (InstanceState, error) {
+	provider, err := NewProviderForNode(ctx, reader, node, credentialsSecret)
+	if err != nil {
+		return StateUnknown, err
+	}
+	return provider.InstanceState(ctx, node.Spec.ProviderID)
+}
+
+func secretValue(secret *corev1.Secret, key string) (string, error) {
+	value := strings.TrimSpace(string(secret.Data[key]))
+	if value == "" {
+		return "", fmt.Errorf("cloud credentials secret %s/%s has no %s", secret.Namespace, secret.Name, key)
+	}
+	return value, nil
+}
@@ -0,0 +1,189 @@
+// Package compat validates at startup that the running cluster is supported by the operator.
+package compat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DegradedType is the type of the condition produced by Result.Condition
+	DegradedType = "Degraded"
+	// ReasonUnsupportedCluster is used when the cluster isn't supported
+	ReasonUnsupportedCluster = "UnsupportedCluster"
+	// ReasonSupportedCluster is used when the cluster is supported
+	ReasonSupportedCluster = "SupportedCluster"
+)
+
+var clusterVersionGVK = schema.GroupVersionKind{Group: "config.openshift.io", Version: "v1", Kind: "ClusterVersion"}
+
+// Requirements are declared by the operator. Empty versions aren't checked.
+type Requirements struct {
+	// MinKubernetesVersion is the oldest supported Kubernetes version, inclusive, e.g. "1.26"
+	MinKubernetesVersion string
+	// MaxKubernetesVersion is the first unsupported Kubernetes version, exclusive, e.g. "1.32"
+	MaxKubernetesVersion string
+	// MinOpenShiftVersion is the oldest supported OpenShift version, inclusive. Only checked on OpenShift.
+	MinOpenShiftVersion string
+	// MaxOpenShiftVersion is the first unsupported OpenShift version, exclusive. Only checked on OpenShift.
+	MaxOpenShiftVersion string
+	// RequiredAPIs are the group versions which need to be served, e.g. coordination.k8s.io/v1
+	RequiredAPIs []schema.GroupVersion
+}
+
+// Result is the outcome of a compatibility check
+type Result struct {
+	KubernetesVersion string
+	// OpenShiftVersion is empty on non OpenShift clusters
+	OpenShiftVersion string
+	// Problems describes every unmet requirement
+	Problems []string
+}
+
+// Compatible returns true if all requirements are met
+func (r *Result) Compatible() bool {
+	return len(r.Problems) == 0
+}
+
+// Message returns a human readable summary
+func (r *Result) Message() string {
+	if r.Compatible() {
+		return fmt.Sprintf("cluster is supported (Kubernetes %s%s)", r.KubernetesVersion, r.openShiftSuffix())
+	}
+	return fmt.Sprintf("cluster is not supported (Kubernetes %s%s): %s", r.KubernetesVersion, r.openShiftSuffix(), strings.Join(r.Problems, "; "))
+}
+
+// Condition returns a Degraded condition reflecting the result
+func (r *Result) Condition(observedGeneration int64) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               DegradedType,
+		Status:             metav1.ConditionFalse,
+		Reason:             ReasonSupportedCluster,
+		Message:            r.Message(),
+		ObservedGeneration: observedGeneration,
+	}
+	if !r.Compatible() {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ReasonUnsupportedCluster
+	}
+	return condition
+}
+
+// RecordEvent emits a Warning event on obj if the cluster isn't supported
+func (r *Result) RecordEvent(recorder record.EventRecorder, obj runtime.Object) {
+	if r.Compatible() {
+		return
+	}
+	recorder.Event(obj, corev1.EventTypeWarning, ReasonUnsupportedCluster, r.Message())
+}
+
+func (r *Result) openShiftSuffix() string {
+	if r.OpenShiftVersion == "" {
+		return ""
+	}
+	return ", OpenShift " + r.OpenShiftVersion
+}
+
+// Check validates the cluster against the requirements. It only returns an error if the cluster couldn't be inspected,
+// unmet requirements are reported in the Result.
+// The reader is used for reading the OpenShift ClusterVersion, and should not be cached.
+func Check(ctx context.Context, disc discovery.DiscoveryInterface, reader client.Reader, req Requirements) (*Result, error) {
+	result := &Result{}
+
+	serverVersion, err := disc.ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server version: %w", err)
+	}
+	result.KubernetesVersion = serverVersion.GitVersion
+	if err := checkRange(result, "Kubernetes", serverVersion.GitVersion, req.MinKubernetesVersion, req.MaxKubernetesVersion); err != nil {
+		return nil, err
+	}
+
+	openShiftVersion, err := getOpenShiftVersion(ctx, reader)
+	if err != nil {
+		return nil, err
+	}
+	result.OpenShiftVersion = openShiftVersion
+	if openShiftVersion != "" {
+		if err := checkRange(result, "OpenShift", openShiftVersion, req.MinOpenShiftVersion, req.MaxOpenShiftVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, gv := range req.RequiredAPIs {
+		if _, err := disc.ServerResourcesForGroupVersion(gv.String()); err != nil {
+			if apierrors.IsNotFound(err) {
+				result.Problems = append(result.Problems, fmt.Sprintf("required API %s is not available", gv))
+				continue
+			}
+			return nil, fmt.Errorf("failed to discover API %s: %w", gv, err)
+		}
+	}
+	return result, nil
+}
+
+func checkRange(result *Result, product, current, min, max string) error {
+	currentVersion, err := version.ParseGeneric(current)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s version %q: %w", product, current, err)
+	}
+	if min != "" {
+		minVersion, err := version.ParseGeneric(min)
+		if err != nil {
+			return fmt.Errorf("invalid minimum %s version %q: %w", product, min, err)
+		}
+		if currentVersion.LessThan(minVersion) {
+			result.Problems = append(result.Problems, fmt.Sprintf("%s version %s is older than the minimum supported version %s", product, current, min))
+		}
+	}
+	if max != "" {
+		maxVersion, err := version.ParseGeneric(max)
+		if err != nil {
+			return fmt.Errorf("invalid maximum %s version %q: %w", product, max, err)
+		}
+		if !currentVersion.LessThan(maxVersion) {
+			result.Problems = append(result.Problems, fmt.Sprintf("%s version %s is not older than the first unsupported version %s", product, current, max))
+		}
+	}
+	return nil
+}
+
+// getOpenShiftVersion returns the current OpenShift version, or an empty string on non OpenShift clusters
+func getOpenShiftVersion(ctx context.Context, reader client.Reader) (string, error) {
+	clusterVersion := &unstructured.Unstructured{}
+	clusterVersion.SetGroupVersionKind(clusterVersionGVK)
+	if err := reader.Get(ctx, client.ObjectKey{Name: "version"}, clusterVersion); err != nil {
+		if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get OpenShift cluster version: %w", err)
+	}
+	// the history is ordered by recency, prefer the latest completed update
+	history, _, _ := unstructured.NestedSlice(clusterVersion.Object, "status", "history")
+	for _, entry := range history {
+		entryMap, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if entryMap["state"] == "Completed" {
+			if v, ok := entryMap["version"].(string); ok {
+				return v, nil
+			}
+		}
+	}
+	desired, _, _ := unstructured.NestedString(clusterVersion.Object, "status", "desired", "version")
+	return desired, nil
+}
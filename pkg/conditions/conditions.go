@@ -0,0 +1,11 @@
+// Package conditions contains the status condition types shared by medik8s remediation CRs.
+package conditions
+
+const (
+	// ProcessingType is the condition type indicating that the remediation is in progress
+	ProcessingType = "Processing"
+	// SucceededType is the condition type indicating whether the remediation succeeded
+	SucceededType = "Succeeded"
+	// PermanentNodeDeletionExpectedType is the condition type indicating that the remediation deletes the node permanently
+	PermanentNodeDeletionExpectedType = "PermanentNodeDeletionExpected"
+)
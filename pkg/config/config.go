@@ -0,0 +1,159 @@
+// Package config loads runtime tunable operator configuration from a ConfigMap.
+package config
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/medik8s/common/pkg/logging"
+)
+
+const (
+	// DefaultReloadInterval is the interval in which the ConfigMap is checked for changes
+	DefaultReloadInterval = 30 * time.Second
+)
+
+var (
+	log = ctrl.Log.WithName("config")
+	// reload errors repeat on every interval as long as the ConfigMap is invalid
+	reloadErrorLog = logging.Every(5 * time.Minute)
+)
+
+// Subscriber is notified with the sorted keys whose values changed after a reload
+type Subscriber func(changedKeys []string)
+
+// Loader holds the current configuration, and reloads it from the ConfigMap when started.
+// Until the first successful load, and as long as the ConfigMap doesn't exist, the schema defaults are used.
+// Invalid ConfigMap content is rejected and the last valid configuration is kept.
+type Loader struct {
+	reader         client.Reader
+	key            client.ObjectKey
+	schema         Schema
+	reloadInterval time.Duration
+
+	lock        sync.RWMutex
+	values      map[string]interface{}
+	subscribers []Subscriber
+}
+
+var _ manager.Runnable = &Loader{}
+
+// NewLoader creates a new Loader for the ConfigMap identified by key. It returns an error if the schema defaults are invalid.
+func NewLoader(reader client.Reader, key client.ObjectKey, schema Schema, reloadInterval time.Duration) (*Loader, error) {
+	defaults, err := schema.parse(nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema defaults: %w", err)
+	}
+	if reloadInterval <= 0 {
+		reloadInterval = DefaultReloadInterval
+	}
+	return &Loader{
+		reader:         reader,
+		key:            key,
+		schema:         schema,
+		reloadInterval: reloadInterval,
+		values:         defaults,
+	}, nil
+}
+
+// Start reloads the configuration periodically until the context is cancelled. It implements manager.Runnable.
+func (l *Loader) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := l.Reload(ctx); err != nil {
+			reloadErrorLog.Error(log, err, l.key.String(), "failed to reload configuration, keeping previous values", "configMap", l.key)
+			return
+		}
+		reloadErrorLog.Reset(l.key.String())
+	}, l.reloadInterval)
+	return nil
+}
+
+// Reload reads the ConfigMap, and applies and publishes its content if it is valid.
+func (l *Loader) Reload(ctx context.Context) error {
+	cm := &corev1.ConfigMap{}
+	var data map[string]string
+	if err := l.reader.Get(ctx, l.key, cm); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get configuration ConfigMap: %w", err)
+		}
+	} else {
+		data = cm.Data
+	}
+	return l.apply(data)
+}
+
+// Subscribe registers a func which is called after the configuration changed.
+func (l *Loader) Subscribe(subscriber Subscriber) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.subscribers = append(l.subscribers, subscriber)
+}
+
+// String returns the value of a String key
+func (l *Loader) String(key string) string {
+	value, _ := l.get(key).(string)
+	return value
+}
+
+// Int returns the value of an Int key
+func (l *Loader) Int(key string) int {
+	value, _ := l.get(key).(int)
+	return value
+}
+
+// Bool returns the value of a Bool key
+func (l *Loader) Bool(key string) bool {
+	value, _ := l.get(key).(bool)
+	return value
+}
+
+// Duration returns the value of a Duration key
+func (l *Loader) Duration(key string) time.Duration {
+	value, _ := l.get(key).(time.Duration)
+	return value
+}
+
+func (l *Loader) get(key string) interface{} {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.values[key]
+}
+
+func (l *Loader) apply(data map[string]string) error {
+	values, err := l.schema.parse(data)
+	if err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	var changed []string
+	for key, value := range values {
+		if !equality.Semantic.DeepEqual(l.values[key], value) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	l.values = values
+	subscribers := append([]Subscriber(nil), l.subscribers...)
+	l.lock.Unlock()
+
+	if len(changed) == 0 {
+		return nil
+	}
+	log.Info("configuration changed", "configMap", l.key, "keys", changed)
+	for _, subscriber := range subscribers {
+		subscriber(changed)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var (
+	key = client.ObjectKey{Namespace: "ns", Name: "config"}
+
+	schema = Schema{
+		"name":     {Type: String, Default: "default"},
+		"replicas": {Type: Int, Default: "3", Validate: IntRange(1, 5)},
+		"enabled":  {Type: Bool, Default: "false"},
+		"timeout":  {Type: Duration, Default: "1m", Validate: MinDuration(time.Second)},
+	}
+)
+
+func configMap(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}, Data: data}
+}
+
+func TestLoaderDefaultsWithoutConfigMap(t *testing.T) {
+	loader, err := NewLoader(fake.NewClientBuilder().Build(), key, schema, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := loader.Reload(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loader.String("name") != "default" || loader.Int("replicas") != 3 || loader.Bool("enabled") || loader.Duration("timeout") != time.Minute {
+		t.Errorf("expected the defaults, got %v", loader.values)
+	}
+}
+
+func TestLoaderInvalidDefaults(t *testing.T) {
+	if _, err := NewLoader(fake.NewClientBuilder().Build(), key, Schema{"replicas": {Type: Int, Default: "many"}}, 0); err == nil {
+		t.Errorf("expected error for invalid defaults")
+	}
+}
+
+func TestLoaderReload(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     map[string]string
+		valid    bool
+		replicas int
+		changed  []string
+	}{
+		{
+			name:     "changed keys are published",
+			data:     map[string]string{"replicas": "2", "enabled": "true", "name": "default"},
+			valid:    true,
+			replicas: 2,
+			changed:  []string{"enabled", "replicas"},
+		},
+		{
+			name:     "unchanged values aren't published",
+			data:     map[string]string{"replicas": "3"},
+			valid:    true,
+			replicas: 3,
+		},
+		{
+			name:     "unknown key keeps the previous config",
+			data:     map[string]string{"replicas": "2", "replica": "2"},
+			replicas: 3,
+		},
+		{
+			name:     "unparsable value keeps the previous config",
+			data:     map[string]string{"replicas": "2", "timeout": "soon"},
+			replicas: 3,
+		},
+		{
+			name:     "invalid value keeps the previous config",
+			data:     map[string]string{"replicas": "7"},
+			replicas: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithObjects(configMap(tt.data)).Build()
+			loader, err := NewLoader(cl, key, schema, 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var published [][]string
+			loader.Subscribe(func(changedKeys []string) { published = append(published, changedKeys) })
+
+			err = loader.Reload(context.Background())
+			if tt.valid != (err == nil) {
+				t.Fatalf("expected valid %t, got error %v", tt.valid, err)
+			}
+			if replicas := loader.Int("replicas"); replicas != tt.replicas {
+				t.Errorf("expected %d replicas, got %d", tt.replicas, replicas)
+			}
+			var expected [][]string
+			if tt.changed != nil {
+				expected = [][]string{tt.changed}
+			}
+			if !reflect.DeepEqual(published, expected) {
+				t.Errorf("expected subscribers to be called with %v, got %v", expected, published)
+			}
+		})
+	}
+}
+
+func TestLoaderKeepsLastValidConfig(t *testing.T) {
+	cm := configMap(map[string]string{"replicas": "2"})
+	cl := fake.NewClientBuilder().WithObjects(cm).Build()
+	loader, err := NewLoader(cl, key, schema, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := loader.Reload(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cm.Data = map[string]string{"replicas": "4", "unknown": "x"}
+	if err := cl.Update(context.Background(), cm); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := loader.Reload(context.Background()); err == nil {
+		t.Fatalf("expected error for unknown key")
+	}
+	if replicas := loader.Int("replicas"); replicas != 2 {
+		t.Errorf("expected the last valid config with 2 replicas, got %d", replicas)
+	}
+
+	if err := cl.Delete(context.Background(), cm); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := loader.Reload(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replicas := loader.Int("replicas"); replicas != 3 {
+		t.Errorf("expected the default of 3 replicas after the ConfigMap was deleted, got %d", replicas)
+	}
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// FieldType is the type of a configuration value
+type FieldType string
+
+const (
+	// String values are taken as is
+	String FieldType = "string"
+	// Int values are parsed with strconv.Atoi
+	Int FieldType = "int"
+	// Bool values are parsed with strconv.ParseBool
+	Bool FieldType = "bool"
+	// Duration values are parsed with time.ParseDuration
+	Duration FieldType = "duration"
+)
+
+// Field describes a single configuration key
+type Field struct {
+	// Type of the value
+	Type FieldType
+	// Default is used when the key isn't set in the ConfigMap. It has to be parsable as Type.
+	Default string
+	// Validate optionally checks the parsed value, e.g. for ranges
+	Validate func(value interface{}) error
+}
+
+// Schema maps the keys of the ConfigMap to their field descriptions.
+// Keys in the ConfigMap which aren't part of the schema are rejected.
+type Schema map[string]Field
+
+// parse parses and validates the given raw data. Missing keys get their default value.
+func (s Schema) parse(data map[string]string) (map[string]interface{}, error) {
+	for key := range data {
+		if _, exists := s[key]; !exists {
+			return nil, fmt.Errorf("unknown configuration key %q", key)
+		}
+	}
+	values := make(map[string]interface{}, len(s))
+	for key, field := range s {
+		raw, exists := data[key]
+		if !exists {
+			raw = field.Default
+		}
+		value, err := field.parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for configuration key %q: %w", raw, key, err)
+		}
+		if field.Validate != nil {
+			if err := field.Validate(value); err != nil {
+				return nil, fmt.Errorf("invalid value %q for configuration key %q: %w", raw, key, err)
+			}
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+func (f Field) parse(raw string) (interface{}, error) {
+	switch f.Type {
+	case String:
+		return raw, nil
+	case Int:
+		return strconv.Atoi(raw)
+	case Bool:
+		return strconv.ParseBool(raw)
+	case Duration:
+		return time.ParseDuration(raw)
+	default:
+		return nil, fmt.Errorf("unsupported field type %q", f.Type)
+	}
+}
+
+// MinDuration returns a validation func which rejects durations shorter than min
+func MinDuration(min time.Duration) func(value interface{}) error {
+	return func(value interface{}) error {
+		if d, ok := value.(time.Duration); ok && d < min {
+			return fmt.Errorf("duration must not be shorter than %s", min)
+		}
+		return nil
+	}
+}
+
+// IntRange returns a validation func which rejects ints outside of [min, max]
+func IntRange(min, max int) func(value interface{}) error {
+	return func(value interface{}) error {
+		if i, ok := value.(int); ok && (i < min || i > max) {
+			return fmt.Errorf("value must be between %d and %d", min, max)
+		}
+		return nil
+	}
+}
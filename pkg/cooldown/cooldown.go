@@ -0,0 +1,149 @@
+// Package cooldown enforces an exponentially growing cool-down between failed remediations of the same node.
+// The state is persisted in node annotations, so it is shared between operators and survives restarts.
+package cooldown
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// FailuresAnnotation holds the number of consecutive failed remediations of the node
+	FailuresAnnotation = "remediation.medik8s.io/failed-remediations"
+	// LastFailureAnnotation holds the RFC3339 time of the last failed remediation of the node
+	LastFailureAnnotation = "remediation.medik8s.io/last-failed-remediation"
+
+	// DefaultBaseCooldown is the default cool-down after the first failure
+	DefaultBaseCooldown = 5 * time.Minute
+	// DefaultMaxCooldown is the default maximum cool-down
+	DefaultMaxCooldown = 2 * time.Hour
+)
+
+// Tracker computes and records cool-downs
+type Tracker struct {
+	client       client.Client
+	apiReader    client.Reader
+	baseCooldown time.Duration
+	maxCooldown  time.Duration
+}
+
+// NewTracker creates a Tracker. The cool-down starts at baseCooldown and doubles with every consecutive failure,
+// up to maxCooldown. Non positive values are replaced by the defaults. apiReader reads the node again on conflicts,
+// it should be uncached, usually the manager's APIReader, since a cache returns the same stale node. If nil, cl is used.
+func NewTracker(cl client.Client, apiReader client.Reader, baseCooldown, maxCooldown time.Duration) *Tracker {
+	if baseCooldown <= 0 {
+		baseCooldown = DefaultBaseCooldown
+	}
+	if maxCooldown <= 0 {
+		maxCooldown = DefaultMaxCooldown
+	}
+	if maxCooldown < baseCooldown {
+		maxCooldown = baseCooldown
+	}
+	if apiReader == nil {
+		apiReader = cl
+	}
+	return &Tracker{
+		client:       cl,
+		apiReader:    apiReader,
+		baseCooldown: baseCooldown,
+		maxCooldown:  maxCooldown,
+	}
+}
+
+// ShouldRetryNow returns true if the node can be remediated now. Otherwise it also returns the remaining cool-down.
+// Nodes with unparsable annotations can be remediated.
+func (t *Tracker) ShouldRetryNow(node *corev1.Node) (bool, time.Duration) {
+	failures, lastFailure, ok := getState(node)
+	if !ok || failures == 0 {
+		return true, 0
+	}
+	remaining := time.Until(lastFailure.Add(t.Cooldown(failures)))
+	if remaining <= 0 {
+		return true, 0
+	}
+	return false, remaining
+}
+
+// Cooldown returns the cool-down after the given number of consecutive failures
+func (t *Tracker) Cooldown(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	cooldown := t.baseCooldown
+	for i := 1; i < failures && cooldown < t.maxCooldown; i++ {
+		cooldown *= 2
+	}
+	if cooldown > t.maxCooldown {
+		cooldown = t.maxCooldown
+	}
+	return cooldown
+}
+
+// RecordFailure increments the node's failure counter and sets the time of the last failure to now.
+// The counter is incremented with an optimistic lock, on conflicts the node is read again and the update retried.
+func (t *Tracker) RecordFailure(ctx context.Context, node *corev1.Node) error {
+	refresh := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if refresh {
+			if err := t.apiReader.Get(ctx, client.ObjectKeyFromObject(node), node); err != nil {
+				return err
+			}
+		}
+		refresh = true
+
+		failures, _, ok := getState(node)
+		if !ok {
+			failures = 0
+		}
+		patch := client.MergeFromWithOptions(node.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		if node.Annotations == nil {
+			node.Annotations = make(map[string]string)
+		}
+		node.Annotations[FailuresAnnotation] = strconv.Itoa(failures + 1)
+		node.Annotations[LastFailureAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		return t.client.Patch(ctx, node, patch)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record remediation failure of node %s: %w", node.Name, err)
+	}
+	return nil
+}
+
+// Reset removes the cool-down state, it should be called after a successful remediation.
+func (t *Tracker) Reset(ctx context.Context, node *corev1.Node) error {
+	_, hasFailures := node.Annotations[FailuresAnnotation]
+	_, hasLastFailure := node.Annotations[LastFailureAnnotation]
+	if !hasFailures && !hasLastFailure {
+		return nil
+	}
+	patch := client.MergeFrom(node.DeepCopy())
+	delete(node.Annotations, FailuresAnnotation)
+	delete(node.Annotations, LastFailureAnnotation)
+	if err := t.client.Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to reset remediation cool-down of node %s: %w", node.Name, err)
+	}
+	return nil
+}
+
+func getState(node *corev1.Node) (int, time.Time, bool) {
+	failuresValue, exists := node.Annotations[FailuresAnnotation]
+	if !exists {
+		return 0, time.Time{}, true
+	}
+	failures, err := strconv.Atoi(failuresValue)
+	if err != nil || failures < 0 {
+		return 0, time.Time{}, false
+	}
+	lastFailure, err := time.Parse(time.RFC3339, node.Annotations[LastFailureAnnotation])
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	return failures, lastFailure, true
+}
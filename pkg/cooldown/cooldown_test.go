@@ -0,0 +1,163 @@
+package cooldown
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestCooldown(t *testing.T) {
+	tracker := NewTracker(nil, nil, time.Minute, 5*time.Minute)
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{failures: 0, expected: 0},
+		{failures: 1, expected: time.Minute},
+		{failures: 2, expected: 2 * time.Minute},
+		{failures: 3, expected: 4 * time.Minute},
+		{failures: 4, expected: 5 * time.Minute},
+		{failures: 100, expected: 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if cooldown := tracker.Cooldown(tt.failures); cooldown != tt.expected {
+			t.Errorf("%d failures: expected %s, got %s", tt.failures, tt.expected, cooldown)
+		}
+	}
+}
+
+func TestShouldRetryNow(t *testing.T) {
+	tracker := NewTracker(nil, nil, time.Minute, time.Hour)
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		retry       bool
+	}{
+		{name: "no failures", annotations: nil, retry: true},
+		{name: "within cool-down", annotations: map[string]string{
+			FailuresAnnotation:    "2",
+			LastFailureAnnotation: time.Now().UTC().Format(time.RFC3339),
+		}, retry: false},
+		{name: "cool-down expired", annotations: map[string]string{
+			FailuresAnnotation:    "2",
+			LastFailureAnnotation: time.Now().Add(-3 * time.Minute).UTC().Format(time.RFC3339),
+		}, retry: true},
+		{name: "unparsable", annotations: map[string]string{
+			FailuresAnnotation:    "x",
+			LastFailureAnnotation: time.Now().UTC().Format(time.RFC3339),
+		}, retry: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", Annotations: tt.annotations}}
+			retry, remaining := tracker.ShouldRetryNow(node)
+			if retry != tt.retry {
+				t.Errorf("expected retry %t, got %t", tt.retry, retry)
+			}
+			if retry && remaining != 0 || !retry && remaining <= 0 {
+				t.Errorf("unexpected remaining cool-down %s", remaining)
+			}
+		})
+	}
+}
+
+func TestRecordFailureAndReset(t *testing.T) {
+	ctx := context.Background()
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}}
+	cl := fake.NewClientBuilder().WithObjects(node).Build()
+	tracker := NewTracker(cl, nil, time.Minute, time.Hour)
+
+	for i := 1; i <= 2; i++ {
+		if err := tracker.RecordFailure(ctx, node); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		stored := &corev1.Node{}
+		if err := cl.Get(ctx, client.ObjectKeyFromObject(node), stored); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if stored.Annotations[FailuresAnnotation] != strconv.Itoa(i) {
+			t.Errorf("expected %d failures, got %s", i, stored.Annotations[FailuresAnnotation])
+		}
+	}
+
+	if err := tracker.Reset(ctx, node); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stored := &corev1.Node{}
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(node), stored); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, exists := stored.Annotations[FailuresAnnotation]; exists {
+		t.Errorf("expected failures annotation to be removed")
+	}
+}
+
+func TestRecordFailureConflict(t *testing.T) {
+	ctx := context.Background()
+	cl := fake.NewClientBuilder().WithObjects(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}}).Build()
+	tracker := NewTracker(cl, nil, time.Minute, time.Hour)
+
+	stale := &corev1.Node{}
+	if err := cl.Get(ctx, client.ObjectKey{Name: "node"}, stale); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// another operator records failures in the meantime
+	current := stale.DeepCopy()
+	current.Annotations = map[string]string{FailuresAnnotation: "3", LastFailureAnnotation: time.Now().UTC().Format(time.RFC3339)}
+	if err := cl.Update(ctx, current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := tracker.RecordFailure(ctx, stale); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stored := &corev1.Node{}
+	if err := cl.Get(ctx, client.ObjectKey{Name: "node"}, stored); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored.Annotations[FailuresAnnotation] != "4" {
+		t.Errorf("expected the concurrent failures to be kept, got %s failures", stored.Annotations[FailuresAnnotation])
+	}
+}
+
+func TestRecordFailureConflictWithCache(t *testing.T) {
+	ctx := context.Background()
+	live := fake.NewClientBuilder().WithObjects(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}}).Build()
+	stale := &corev1.Node{}
+	if err := live.Get(ctx, client.ObjectKey{Name: "node"}, stale); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	current := stale.DeepCopy()
+	current.Annotations = map[string]string{FailuresAnnotation: "3", LastFailureAnnotation: time.Now().UTC().Format(time.RFC3339)}
+	if err := live.Update(ctx, current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// a cache which didn't see the update yet
+	cached := fake.NewClientBuilder().WithObjects(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}}).WithInterceptorFuncs(interceptor.Funcs{
+		Get: func(ctx context.Context, _ client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			stale.DeepCopyInto(obj.(*corev1.Node))
+			return nil
+		},
+		Patch: func(ctx context.Context, _ client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			return live.Patch(ctx, obj, patch, opts...)
+		},
+	}).Build()
+
+	if err := NewTracker(cached, live, time.Minute, time.Hour).RecordFailure(ctx, stale.DeepCopy()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stored := &corev1.Node{}
+	if err := live.Get(ctx, client.ObjectKey{Name: "node"}, stored); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored.Annotations[FailuresAnnotation] != "4" {
+		t.Errorf("expected the node to be read with the API reader on conflicts, got %s failures", stored.Annotations[FailuresAnnotation])
+	}
+}
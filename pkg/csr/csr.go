@@ -0,0 +1,145 @@
+// Package csr approves the kubelet serving certificate requests of remediated nodes,
+// which otherwise can stay NotReady while waiting for approval.
+package csr
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"strings"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	nodeUserPrefix = "system:node:"
+	nodesGroup     = "system:nodes"
+
+	// ApprovalReason is the reason of the Approved condition set on approved CSRs
+	ApprovalReason = "Medik8sNodeRemediation"
+)
+
+var log = ctrl.Log.WithName("csr")
+
+// ApprovePendingServingCSRs approves all pending kubelet serving CSRs of the node which pass validation:
+// the requestor and the certificate subject have to be the node itself, and all SANs have to be addresses or
+// host names of the node. It returns the names of the approved CSRs. Invalid CSRs are skipped and logged.
+func ApprovePendingServingCSRs(ctx context.Context, cl client.Client, node *corev1.Node) ([]string, error) {
+	csrs := &certificatesv1.CertificateSigningRequestList{}
+	if err := cl.List(ctx, csrs); err != nil {
+		return nil, fmt.Errorf("failed to list CSRs: %w", err)
+	}
+
+	var approved []string
+	var errs []error
+	for i := range csrs.Items {
+		csr := &csrs.Items[i]
+		if csr.Spec.SignerName != certificatesv1.KubeletServingSignerName || !isPending(csr) || csr.Spec.Username != nodeUserPrefix+node.Name {
+			continue
+		}
+		if err := Validate(csr, node); err != nil {
+			log.Info("skipping invalid kubelet serving CSR", "csr", csr.Name, "node", node.Name, "reason", err.Error())
+			continue
+		}
+		csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+			Type:           certificatesv1.CertificateApproved,
+			Status:         corev1.ConditionTrue,
+			Reason:         ApprovalReason,
+			Message:        fmt.Sprintf("Approved after remediation of node %s", node.Name),
+			LastUpdateTime: metav1.Now(),
+		})
+		if err := cl.SubResource("approval").Update(ctx, csr); err != nil {
+			errs = append(errs, fmt.Errorf("failed to approve CSR %s: %w", csr.Name, err))
+			continue
+		}
+		log.Info("approved kubelet serving CSR", "csr", csr.Name, "node", node.Name)
+		approved = append(approved, csr.Name)
+	}
+	return approved, utilerrors.NewAggregate(errs)
+}
+
+// Validate checks that the kubelet serving CSR was requested by the node for itself, and only contains SANs of the node.
+func Validate(csr *certificatesv1.CertificateSigningRequest, node *corev1.Node) error {
+	expectedUser := nodeUserPrefix + node.Name
+	if csr.Spec.Username != expectedUser {
+		return fmt.Errorf("requestor %s isn't %s", csr.Spec.Username, expectedUser)
+	}
+	if !contains(csr.Spec.Groups, nodesGroup) {
+		return fmt.Errorf("requestor isn't member of %s", nodesGroup)
+	}
+
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return fmt.Errorf("request isn't a PEM encoded certificate request")
+	}
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse certificate request: %w", err)
+	}
+	if request.Subject.CommonName != expectedUser {
+		return fmt.Errorf("subject common name %s isn't %s", request.Subject.CommonName, expectedUser)
+	}
+	if len(request.Subject.Organization) != 1 || request.Subject.Organization[0] != nodesGroup {
+		return fmt.Errorf("subject organization %v isn't [%s]", request.Subject.Organization, nodesGroup)
+	}
+	if len(request.EmailAddresses) > 0 || len(request.URIs) > 0 {
+		return fmt.Errorf("request contains email or URI SANs")
+	}
+	if len(request.DNSNames) == 0 && len(request.IPAddresses) == 0 {
+		return fmt.Errorf("request contains no SANs")
+	}
+
+	hostNames, ips := nodeAddresses(node)
+	for _, dnsName := range request.DNSNames {
+		if !hostNames[strings.ToLower(dnsName)] {
+			return fmt.Errorf("DNS SAN %s isn't a host name of the node", dnsName)
+		}
+	}
+	for _, ip := range request.IPAddresses {
+		if !ips[ip.String()] {
+			return fmt.Errorf("IP SAN %s isn't an address of the node", ip)
+		}
+	}
+	return nil
+}
+
+func nodeAddresses(node *corev1.Node) (map[string]bool, map[string]bool) {
+	hostNames := map[string]bool{strings.ToLower(node.Name): true}
+	ips := make(map[string]bool)
+	for _, address := range node.Status.Addresses {
+		switch address.Type {
+		case corev1.NodeHostName, corev1.NodeInternalDNS, corev1.NodeExternalDNS:
+			hostNames[strings.ToLower(address.Address)] = true
+		case corev1.NodeInternalIP, corev1.NodeExternalIP:
+			if ip := net.ParseIP(address.Address); ip != nil {
+				ips[ip.String()] = true
+			}
+		}
+	}
+	return hostNames, ips
+}
+
+func isPending(csr *certificatesv1.CertificateSigningRequest) bool {
+	for _, condition := range csr.Status.Conditions {
+		if condition.Type == certificatesv1.CertificateApproved || condition.Type == certificatesv1.CertificateDenied || condition.Type == certificatesv1.CertificateFailed {
+			return false
+		}
+	}
+	return len(csr.Status.Certificate) == 0
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
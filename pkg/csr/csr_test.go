@@ -0,0 +1,127 @@
+package csr
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"net/url"
+	"testing"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testNode() *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-0"},
+		Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+			{Type: corev1.NodeHostName, Address: "worker-0.example.com"},
+			{Type: corev1.NodeInternalIP, Address: "10.0.0.10"},
+		}},
+	}
+}
+
+func request(t *testing.T, template *x509.CertificateRequest) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate request: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+func TestValidate(t *testing.T) {
+	validSubject := pkix.Name{CommonName: "system:node:worker-0", Organization: []string{"system:nodes"}}
+	tests := []struct {
+		name     string
+		username string
+		groups   []string
+		template *x509.CertificateRequest
+		raw      []byte
+		valid    bool
+	}{
+		{
+			name:     "valid",
+			template: &x509.CertificateRequest{Subject: validSubject, DNSNames: []string{"worker-0", "Worker-0.example.com"}, IPAddresses: []net.IP{net.ParseIP("10.0.0.10")}},
+			valid:    true,
+		},
+		{
+			name:     "other requestor",
+			username: "system:node:worker-1",
+			template: &x509.CertificateRequest{Subject: validSubject, DNSNames: []string{"worker-0"}},
+		},
+		{
+			name:     "requestor not in nodes group",
+			groups:   []string{"system:authenticated"},
+			template: &x509.CertificateRequest{Subject: validSubject, DNSNames: []string{"worker-0"}},
+		},
+		{
+			name: "not a PEM request",
+			raw:  []byte("garbage"),
+		},
+		{
+			name:     "wrong common name",
+			template: &x509.CertificateRequest{Subject: pkix.Name{CommonName: "system:node:worker-1", Organization: []string{"system:nodes"}}, DNSNames: []string{"worker-0"}},
+		},
+		{
+			name:     "wrong organization",
+			template: &x509.CertificateRequest{Subject: pkix.Name{CommonName: "system:node:worker-0", Organization: []string{"system:masters"}}, DNSNames: []string{"worker-0"}},
+		},
+		{
+			name:     "no SANs",
+			template: &x509.CertificateRequest{Subject: validSubject},
+		},
+		{
+			name:     "foreign DNS SAN",
+			template: &x509.CertificateRequest{Subject: validSubject, DNSNames: []string{"api.example.com"}},
+		},
+		{
+			name:     "foreign IP SAN",
+			template: &x509.CertificateRequest{Subject: validSubject, IPAddresses: []net.IP{net.ParseIP("10.0.0.11")}},
+		},
+		{
+			name:     "email SAN",
+			template: &x509.CertificateRequest{Subject: validSubject, DNSNames: []string{"worker-0"}, EmailAddresses: []string{"a@example.com"}},
+		},
+		{
+			name:     "URI SAN",
+			template: &x509.CertificateRequest{Subject: validSubject, DNSNames: []string{"worker-0"}, URIs: []*url.URL{{Scheme: "spiffe", Host: "example.com"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csr := &certificatesv1.CertificateSigningRequest{
+				Spec: certificatesv1.CertificateSigningRequestSpec{
+					Username: "system:node:worker-0",
+					Groups:   []string{"system:nodes", "system:authenticated"},
+					Request:  tt.raw,
+				},
+			}
+			if tt.username != "" {
+				csr.Spec.Username = tt.username
+			}
+			if tt.groups != nil {
+				csr.Spec.Groups = tt.groups
+			}
+			if tt.template != nil {
+				csr.Spec.Request = request(t, tt.template)
+			}
+			err := Validate(csr, testNode())
+			if tt.valid && err != nil {
+				t.Errorf("expected valid CSR, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("expected invalid CSR")
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// Package debug provides an optional HTTP endpoint reporting the state of this library as JSON,
+// for debugging stuck remediations in the field.
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/common/pkg/etcd"
+	leaseutil "github.com/medik8s/common/pkg/lease"
+	"github.com/medik8s/common/pkg/semaphore"
+)
+
+const (
+	// DefaultPath is the suggested path of the endpoint
+	DefaultPath = "/debug/medik8s"
+
+	providerTimeout = 10 * time.Second
+)
+
+// Provider returns a JSON serializable state snapshot
+type Provider func(ctx context.Context) (interface{}, error)
+
+// Handler serves the snapshots of all registered providers as a JSON object keyed by provider name.
+// It can be plugged into the operator's existing server, e.g. as an extra handler of the metrics server.
+type Handler struct {
+	lock      sync.RWMutex
+	providers map[string]Provider
+}
+
+var _ http.Handler = &Handler{}
+
+// NewHandler creates a Handler without providers
+func NewHandler() *Handler {
+	return &Handler{providers: make(map[string]Provider)}
+}
+
+// Register adds or replaces a provider
+func (h *Handler) Register(name string, provider Provider) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.providers[name] = provider
+}
+
+// ServeHTTP implements http.Handler. Failing providers are reported with their error instead of their state.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.lock.RLock()
+	names := make([]string, 0, len(h.providers))
+	for name := range h.providers {
+		names = append(names, name)
+	}
+	providers := make(map[string]Provider, len(h.providers))
+	for name, provider := range h.providers {
+		providers[name] = provider
+	}
+	h.lock.RUnlock()
+	sort.Strings(names)
+
+	ctx, cancel := context.WithTimeout(req.Context(), providerTimeout)
+	defer cancel()
+	response := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		state, err := providers[name](ctx)
+		if err != nil {
+			response[name] = map[string]string{"error": err.Error()}
+			continue
+		}
+		response[name] = state
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// HeldLease is the reported state of a lease
+type HeldLease struct {
+	Name        string     `json:"name"`
+	AcquireTime *time.Time `json:"acquireTime,omitempty"`
+	RenewTime   *time.Time `json:"renewTime,omitempty"`
+	Duration    string     `json:"duration,omitempty"`
+	ExpiryTime  *time.Time `json:"expiryTime,omitempty"`
+}
+
+// HeldLeasesProvider reports the leases in namespace held by holderIdentity
+func HeldLeasesProvider(reader client.Reader, namespace, holderIdentity string) Provider {
+	return func(ctx context.Context) (interface{}, error) {
+		leases := &coordv1.LeaseList{}
+		if err := reader.List(ctx, leases, client.InNamespace(namespace)); err != nil {
+			return nil, err
+		}
+		held := []HeldLease{}
+		for _, lease := range leases.Items {
+			if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != holderIdentity {
+				continue
+			}
+			heldLease := HeldLease{Name: lease.Name}
+			if lease.Spec.AcquireTime != nil {
+				heldLease.AcquireTime = &lease.Spec.AcquireTime.Time
+			}
+			if lease.Spec.RenewTime != nil {
+				heldLease.RenewTime = &lease.Spec.RenewTime.Time
+			}
+			if lease.Spec.LeaseDurationSeconds != nil {
+				heldLease.Duration = (time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second).String()
+			}
+			if expiry, ok := leaseutil.LeaseExpiry(&lease); ok {
+				heldLease.ExpiryTime = &expiry
+			}
+			held = append(held, heldLease)
+		}
+		return held, nil
+	}
+}
+
+// EtcdDecisionsProvider reports the latest etcd disruption decision of every node checked by the checker. It doesn't
+// check etcd itself, so it neither lists any objects nor emits events.
+func EtcdDecisionsProvider(checker *etcd.EtcdChecker) Provider {
+	return func(_ context.Context) (interface{}, error) {
+		return checker.LastDecisions(), nil
+	}
+}
+
+// SemaphoreProvider reports the nodes holding slots of the given pools
+func SemaphoreProvider(sem *semaphore.Semaphore, pools ...string) Provider {
+	return func(ctx context.Context) (interface{}, error) {
+		occupancy := make(map[string][]string, len(pools))
+		for _, pool := range pools {
+			holders, err := sem.Holders(ctx, pool)
+			if err != nil {
+				return nil, err
+			}
+			occupancy[pool] = holders
+		}
+		return occupancy, nil
+	}
+}
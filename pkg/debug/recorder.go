@@ -0,0 +1,98 @@
+package debug
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// DefaultRecorderSize is the default number of events kept by a Recorder
+const DefaultRecorderSize = 100
+
+// RecordedEvent is an event emitted through a Recorder
+type RecordedEvent struct {
+	Time      time.Time `json:"time"`
+	Kind      string    `json:"kind,omitempty"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Type      string    `json:"type"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+}
+
+// Recorder wraps an EventRecorder and keeps the most recent events in memory
+type Recorder struct {
+	record.EventRecorder
+
+	lock   sync.Mutex
+	size   int
+	events []RecordedEvent
+}
+
+var _ record.EventRecorder = &Recorder{}
+
+// NewRecorder wraps the given recorder. A non positive size is replaced by DefaultRecorderSize.
+func NewRecorder(recorder record.EventRecorder, size int) *Recorder {
+	if size <= 0 {
+		size = DefaultRecorderSize
+	}
+	return &Recorder{EventRecorder: recorder, size: size}
+}
+
+// Event implements record.EventRecorder
+func (r *Recorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.remember(object, eventtype, reason, message)
+	r.EventRecorder.Event(object, eventtype, reason, message)
+}
+
+// Eventf implements record.EventRecorder
+func (r *Recorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.remember(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+	r.EventRecorder.Eventf(object, eventtype, reason, messageFmt, args...)
+}
+
+// AnnotatedEventf implements record.EventRecorder
+func (r *Recorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.remember(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+	r.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
+}
+
+// Events returns the remembered events, oldest first
+func (r *Recorder) Events() []RecordedEvent {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]RecordedEvent(nil), r.events...)
+}
+
+// Provider reports the remembered events
+func (r *Recorder) Provider() Provider {
+	return func(_ context.Context) (interface{}, error) {
+		return r.Events(), nil
+	}
+}
+
+func (r *Recorder) remember(object runtime.Object, eventtype, reason, message string) {
+	event := RecordedEvent{
+		Time:    time.Now(),
+		Kind:    object.GetObjectKind().GroupVersionKind().Kind,
+		Type:    eventtype,
+		Reason:  reason,
+		Message: message,
+	}
+	if accessor, err := meta.Accessor(object); err == nil {
+		event.Namespace = accessor.GetNamespace()
+		event.Name = accessor.GetName()
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, event)
+	if len(r.events) > r.size {
+		r.events = r.events[len(r.events)-r.size:]
+	}
+}
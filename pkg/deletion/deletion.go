@@ -0,0 +1,201 @@
+// Package deletion waits for objects to be fully deleted.
+package deletion
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// restartBackoff delays getting and watching the object again after the watch was closed or failed, so that a
+// persistently failing watch doesn't turn into a hot loop against the API server. It is reset after a watch which
+// lasted longer than its cap.
+var restartBackoff = wait.Backoff{
+	Duration: 100 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    math.MaxInt32,
+	Cap:      10 * time.Second,
+}
+
+// Outcome describes how the wait ended successfully
+type Outcome string
+
+const (
+	// AlreadyGone means the object didn't exist anymore when the wait started
+	AlreadyGone Outcome = "AlreadyGone"
+	// Deleted means the object was deleted while waiting
+	Deleted Outcome = "Deleted"
+)
+
+// FinalizerBlockedError is returned when the object is being deleted, but finalizers kept it around until the timeout
+type FinalizerBlockedError struct {
+	Key        client.ObjectKey
+	Finalizers []string
+}
+
+func (e *FinalizerBlockedError) Error() string {
+	return fmt.Sprintf("deletion of %s is blocked by finalizers: %s", e.Key, strings.Join(e.Finalizers, ", "))
+}
+
+// NotDeletingError is returned when the object wasn't even marked for deletion until the timeout
+type NotDeletingError struct {
+	Key client.ObjectKey
+}
+
+func (e *NotDeletingError) Error() string {
+	return fmt.Sprintf("%s was not deleted", e.Key)
+}
+
+// WaitForDeletion waits until obj, identified by its name, namespace and UID, is gone. An object which is recreated
+// with the same name but a new UID counts as deleted. On timeout a FinalizerBlockedError or a NotDeletingError
+// tells why the object is still there.
+func WaitForDeletion(ctx context.Context, cl client.WithWatch, obj client.Object, timeout time.Duration) (Outcome, error) {
+	gvk, err := apiutil.GVKForObject(obj, cl.Scheme())
+	if err != nil {
+		return "", fmt.Errorf("failed to get GVK of object: %w", err)
+	}
+	key := client.ObjectKeyFromObject(obj)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(gvk)
+	uid := obj.GetUID()
+	firstGet := true
+	backoff := restartBackoff
+	for {
+		if err := cl.Get(ctx, key, current); err != nil {
+			if apierrors.IsNotFound(err) {
+				if firstGet {
+					return AlreadyGone, nil
+				}
+				return Deleted, nil
+			}
+			if ctx.Err() != nil {
+				return "", timeoutError(key, current)
+			}
+			return "", fmt.Errorf("failed to get %s: %w", key, err)
+		}
+		if uid == "" {
+			uid = current.GetUID()
+		}
+		if current.GetUID() != uid {
+			if firstGet {
+				return AlreadyGone, nil
+			}
+			return Deleted, nil
+		}
+		firstGet = false
+
+		watchStart := time.Now()
+		deleted, err := watchForDeletion(ctx, cl, current, uid)
+		if deleted {
+			return Deleted, nil
+		}
+		if ctx.Err() != nil {
+			return "", timeoutError(key, current)
+		}
+		if err != nil {
+			return "", err
+		}
+		// the watch was closed, get the object again and resume after a delay
+		if time.Since(watchStart) > backoff.Cap {
+			backoff = restartBackoff
+		}
+		select {
+		case <-ctx.Done():
+			return "", timeoutError(key, current)
+		case <-time.After(backoff.Step()):
+		}
+	}
+}
+
+// watchForDeletion watches the object until it is deleted, the context is done, or the watch is closed.
+// The last seen state is stored in current.
+func watchForDeletion(ctx context.Context, cl client.WithWatch, current *unstructured.Unstructured, uid types.UID) (bool, error) {
+	list := &unstructured.UnstructuredList{}
+	gvk := current.GroupVersionKind()
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	watcher, err := cl.Watch(ctx, list,
+		client.InNamespace(current.GetNamespace()),
+		client.MatchingFieldsSelector{Selector: fields.OneTermEqualSelector("metadata.name", current.GetName())},
+		&client.ListOptions{Raw: &metav1.ListOptions{ResourceVersion: current.GetResourceVersion()}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to watch %s: %w", client.ObjectKeyFromObject(current), err)
+	}
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return false, nil
+			}
+			obj, isObject := event.Object.(client.Object)
+			if !isObject {
+				// e.g. a Status object for expired resource versions, restart the watch
+				if event.Type == watch.Error {
+					return false, nil
+				}
+				continue
+			}
+			if obj.GetUID() != uid {
+				if event.Type == watch.Added {
+					// recreated
+					return true, nil
+				}
+				continue
+			}
+			if event.Type == watch.Deleted {
+				return true, nil
+			}
+			if err := storeCurrent(obj, current); err != nil {
+				return false, err
+			}
+		}
+	}
+}
+
+// storeCurrent copies obj into current. Watches usually deliver unstructured objects, but e.g. typed clients don't.
+func storeCurrent(obj client.Object, current *unstructured.Unstructured) error {
+	if u, isUnstructured := obj.(*unstructured.Unstructured); isUnstructured {
+		u.DeepCopyInto(current)
+		return nil
+	}
+	gvk := current.GroupVersionKind()
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return fmt.Errorf("failed to convert %s: %w", client.ObjectKeyFromObject(obj), err)
+	}
+	current.SetUnstructuredContent(content)
+	current.SetGroupVersionKind(gvk)
+	return nil
+}
+
+func timeoutError(key client.ObjectKey, current *unstructured.Unstructured) error {
+	if current.GetDeletionTimestamp() == nil {
+		return &NotDeletingError{Key: key}
+	}
+	if finalizers := current.GetFinalizers(); len(finalizers) > 0 {
+		return &FinalizerBlockedError{Key: key, Finalizers: finalizers}
+	}
+	return fmt.Errorf("timed out waiting for deletion of %s", key)
+}
@@ -0,0 +1,115 @@
+package deletion
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func configMap(finalizers ...string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "cm", UID: "uid-1", Finalizers: finalizers}}
+}
+
+func TestWaitForDeletionAlreadyGone(t *testing.T) {
+	tests := []struct {
+		name string
+		objs []client.Object
+	}{
+		{name: "not found"},
+		{name: "recreated with a new UID", objs: []client.Object{&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "cm", UID: "uid-2"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithObjects(tt.objs...).Build()
+			outcome, err := WaitForDeletion(context.Background(), cl, configMap(), time.Second)
+			if err != nil || outcome != AlreadyGone {
+				t.Errorf("expected %s, got %s, %v", AlreadyGone, outcome, err)
+			}
+		})
+	}
+}
+
+func TestWaitForDeletionDeleted(t *testing.T) {
+	cm := configMap()
+	cl := fake.NewClientBuilder().WithObjects(cm).Build()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = cl.Delete(context.Background(), cm.DeepCopy())
+	}()
+	outcome, err := WaitForDeletion(context.Background(), cl, cm, 5*time.Second)
+	if err != nil || outcome != Deleted {
+		t.Errorf("expected %s, got %s, %v", Deleted, outcome, err)
+	}
+}
+
+func TestWaitForDeletionTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		deleting bool
+		check    func(err error) bool
+	}{
+		{
+			name:     "blocked by finalizers",
+			deleting: true,
+			check: func(err error) bool {
+				var blocked *FinalizerBlockedError
+				return errors.As(err, &blocked) && len(blocked.Finalizers) == 1 && blocked.Finalizers[0] == "example.com/block"
+			},
+		},
+		{
+			name: "not deleting",
+			check: func(err error) bool {
+				var notDeleting *NotDeletingError
+				return errors.As(err, &notDeleting)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := configMap("example.com/block")
+			cl := fake.NewClientBuilder().WithObjects(cm).Build()
+			if tt.deleting {
+				// sets the deletion timestamp, the finalizer keeps the object
+				if err := cl.Delete(context.Background(), cm.DeepCopy()); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			_, err := WaitForDeletion(context.Background(), cl, cm, 200*time.Millisecond)
+			if !tt.check(err) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestWaitForDeletionBacksOffFailingWatches(t *testing.T) {
+	var watches atomic.Int32
+	cl := fake.NewClientBuilder().WithObjects(configMap()).WithInterceptorFuncs(interceptor.Funcs{
+		Watch: func(ctx context.Context, client client.WithWatch, obj client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+			watches.Add(1)
+			watcher := watch.NewFake()
+			go watcher.Error(&metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonExpired})
+			return watcher, nil
+		},
+	}).Build()
+
+	_, err := WaitForDeletion(context.Background(), cl, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "cm", UID: types.UID("uid-1")}}, 500*time.Millisecond)
+	var notDeleting *NotDeletingError
+	if !errors.As(err, &notDeleting) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// 100ms, 200ms, 400ms... without the backoff it would be thousands
+	if count := watches.Load(); count < 2 || count > 5 {
+		t.Errorf("expected the failing watch to be restarted with a backoff, got %d watches", count)
+	}
+}
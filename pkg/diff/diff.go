@@ -0,0 +1,210 @@
+// Package diff computes concise, redacted diffs between two versions of an object,
+// for logging what an operator actually changed.
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const redacted = "<redacted>"
+
+// sensitiveWords mark fields whose values are redacted, compared case insensitively
+var sensitiveWords = []string{"password", "token", "secret", "credential"}
+
+// pairValueFields are the fields of name/value pairs, like env vars, which are redacted if the name is sensitive.
+// The values of env lists are always redacted.
+var pairValueFields = []string{"value", "valueFrom"}
+
+// comparedFields are the top level paths which are compared, status and other metadata are ignored
+var comparedFields = [][]string{
+	{"spec"},
+	{"metadata", "labels"},
+	{"metadata", "annotations"},
+	{"metadata", "ownerReferences"},
+	{"metadata", "finalizers"},
+	// Secrets and ConfigMaps have no spec
+	{"data"},
+	{"stringData"},
+	{"binaryData"},
+}
+
+// Change is a single changed field
+type Change struct {
+	// Path is the dot separated path of the field, e.g. spec.holderIdentity
+	Path string
+	// Old is the old value, nil if the field was added
+	Old interface{}
+	// New is the new value, nil if the field was removed
+	New interface{}
+}
+
+// String returns a representation like `spec.holderIdentity: "a" -> "b"`
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Path, format(c.Old), format(c.New))
+}
+
+// Changes returns the redacted changes between the spec and selected metadata of the two objects, sorted by path.
+// Fields with sensitive names, and all data of Secrets, are redacted.
+func Changes(oldObj, newObj client.Object) ([]Change, error) {
+	oldContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(oldObj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert old object: %w", err)
+	}
+	newContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(newObj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert new object: %w", err)
+	}
+	redactAll := isSecret(oldObj) || isSecret(newObj)
+
+	var changes []Change
+	for _, path := range comparedFields {
+		oldValue, _ := nested(oldContent, path)
+		newValue, _ := nested(newContent, path)
+		compare(strings.Join(path, "."), oldValue, newValue, redactAll, &changes)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// Diff returns the changes as a single line, or an empty string if nothing changed.
+func Diff(oldObj, newObj client.Object) (string, error) {
+	changes, err := Changes(oldObj, newObj)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, 0, len(changes))
+	for _, change := range changes {
+		parts = append(parts, change.String())
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+func compare(path string, oldValue, newValue interface{}, redact bool, changes *[]Change) {
+	redact = redact || isSensitive(path)
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := make(map[string]bool)
+		for key := range oldMap {
+			keys[key] = true
+		}
+		for key := range newMap {
+			keys[key] = true
+		}
+		for key := range keys {
+			compare(path+"."+key, oldMap[key], newMap[key], redact, changes)
+		}
+		return
+	}
+	if reflect.DeepEqual(oldValue, newValue) {
+		return
+	}
+	key := path[strings.LastIndex(path, ".")+1:]
+	*changes = append(*changes, Change{Path: path, Old: redactValue(key, oldValue, redact), New: redactValue(key, newValue, redact)})
+}
+
+// redactValue redacts the value of the given key, or the sensitive values within added, removed or changed maps and
+// slices. Within maps, the values of sensitive keys are redacted, and so are the values of name/value pairs with a
+// sensitive name. Within env lists all values are redacted.
+func redactValue(key string, value interface{}, redact bool) interface{} {
+	if value == nil {
+		return nil
+	}
+	if redact {
+		return redacted
+	}
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		name, _ := typed["name"].(string)
+		redactPairValue := isSensitive(name)
+		result := make(map[string]interface{}, len(typed))
+		for nestedKey, nestedValue := range typed {
+			redactNested := isSensitive(nestedKey) || redactPairValue && isPairValueField(nestedKey)
+			result[nestedKey] = redactValue(nestedKey, nestedValue, redactNested)
+		}
+		return result
+	case []interface{}:
+		isEnv := strings.EqualFold(key, "env")
+		result := make([]interface{}, 0, len(typed))
+		for _, item := range typed {
+			if envVar, isMap := item.(map[string]interface{}); isEnv && isMap {
+				item = redactEnvVar(envVar)
+			}
+			result = append(result, redactValue(key, item, false))
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// redactEnvVar returns a copy of the env var with its value redacted, env vars often hold credentials which don't
+// have sensitive names
+func redactEnvVar(envVar map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(envVar))
+	for key, value := range envVar {
+		if isPairValueField(key) {
+			value = redacted
+		}
+		result[key] = value
+	}
+	return result
+}
+
+func isPairValueField(key string) bool {
+	for _, field := range pairValueFields {
+		if key == field {
+			return true
+		}
+	}
+	return false
+}
+
+func nested(content map[string]interface{}, path []string) (interface{}, bool) {
+	var current interface{} = content
+	for _, field := range path {
+		currentMap, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = currentMap[field]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func isSensitive(path string) bool {
+	lowerPath := strings.ToLower(path)
+	for _, word := range sensitiveWords {
+		if strings.Contains(lowerPath, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSecret(obj client.Object) bool {
+	if _, ok := obj.(*corev1.Secret); ok {
+		return true
+	}
+	return obj.GetObjectKind().GroupVersionKind().Kind == "Secret"
+}
+
+func format(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return "<none>"
+	case string:
+		return fmt.Sprintf("%q", typed)
+	default:
+		return fmt.Sprintf("%v", typed)
+	}
+}
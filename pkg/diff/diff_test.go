@@ -0,0 +1,124 @@
+package diff
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func configMap(labels, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "cm", Labels: labels},
+		Data:       data,
+	}
+}
+
+func custom(spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Custom",
+		"metadata":   map[string]interface{}{"namespace": "ns", "name": "c"},
+		"spec":       spec,
+	}}
+}
+
+func TestChanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldObj   client.Object
+		newObj   client.Object
+		expected []string
+	}{
+		{
+			name:     "no changes",
+			oldObj:   configMap(nil, map[string]string{"a": "1"}),
+			newObj:   configMap(nil, map[string]string{"a": "1"}),
+			expected: nil,
+		},
+		{
+			name:     "changed, added and removed fields",
+			oldObj:   configMap(map[string]string{"l": "x"}, map[string]string{"a": "1", "b": "2"}),
+			newObj:   configMap(map[string]string{"l": "y"}, map[string]string{"a": "3", "c": "4"}),
+			expected: []string{`data.a: "1" -> "3"`, `data.b: "2" -> <none>`, `data.c: <none> -> "4"`, `metadata.labels.l: "x" -> "y"`},
+		},
+		{
+			name:     "sensitive field names are redacted",
+			oldObj:   configMap(nil, map[string]string{"dbPassword": "old", "apiToken": "t1"}),
+			newObj:   configMap(nil, map[string]string{"dbPassword": "new", "apiToken": "t2"}),
+			expected: []string{`data.apiToken: "<redacted>" -> "<redacted>"`, `data.dbPassword: "<redacted>" -> "<redacted>"`},
+		},
+		{
+			name:     "added sensitive field is redacted",
+			oldObj:   configMap(nil, map[string]string{"a": "1"}),
+			newObj:   configMap(nil, map[string]string{"a": "1", "secretKey": "value"}),
+			expected: []string{`data.secretKey: <none> -> "<redacted>"`},
+		},
+		{
+			name:     "sensitive fields of added maps are redacted",
+			oldObj:   configMap(nil, nil),
+			newObj:   configMap(nil, map[string]string{"a": "1", "password": "value"}),
+			expected: []string{`data: <none> -> map[a:1 password:<redacted>]`},
+		},
+		{
+			name:     "sensitive fields of removed lists of maps are redacted",
+			oldObj:   custom(map[string]interface{}{"users": []interface{}{map[string]interface{}{"name": "a", "token": "t"}}}),
+			newObj:   custom(map[string]interface{}{}),
+			expected: []string{`spec.users: [map[name:a token:<redacted>]] -> <none>`},
+		},
+		{
+			name: "values of changed env lists are redacted",
+			oldObj: custom(map[string]interface{}{"env": []interface{}{
+				map[string]interface{}{"name": "DB_PASSWORD", "value": "old"},
+			}}),
+			newObj: custom(map[string]interface{}{"env": []interface{}{
+				map[string]interface{}{"name": "DB_PASSWORD", "value": "new"},
+				map[string]interface{}{"name": "DB_HOST", "valueFrom": map[string]interface{}{"secretKeyRef": map[string]interface{}{"name": "db", "key": "host"}}},
+			}}),
+			expected: []string{`spec.env: [map[name:DB_PASSWORD value:<redacted>]] -> [map[name:DB_PASSWORD value:<redacted>] map[name:DB_HOST valueFrom:<redacted>]]`},
+		},
+		{
+			name: "values of pairs with sensitive names are redacted",
+			oldObj: custom(map[string]interface{}{"params": []interface{}{
+				map[string]interface{}{"name": "apiToken", "value": "old"},
+				map[string]interface{}{"name": "user", "value": "admin"},
+			}}),
+			newObj:   custom(map[string]interface{}{"params": []interface{}{}}),
+			expected: []string{`spec.params: [map[name:apiToken value:<redacted>] map[name:user value:admin]] -> []`},
+		},
+		{
+			name:     "all secret data is redacted",
+			oldObj:   &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "s"}, Data: map[string][]byte{"user": []byte("a")}},
+			newObj:   &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "s"}, Data: map[string][]byte{"user": []byte("b")}},
+			expected: []string{`data.user: "<redacted>" -> "<redacted>"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := Changes(tt.oldObj, tt.newObj)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(changes) != len(tt.expected) {
+				t.Fatalf("expected %d changes, got %v", len(tt.expected), changes)
+			}
+			for i, change := range changes {
+				if change.String() != tt.expected[i] {
+					t.Errorf("expected change %s, got %s", tt.expected[i], change.String())
+				}
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	diff, err := Diff(configMap(nil, map[string]string{"a": "1", "b": "1"}), configMap(nil, map[string]string{"a": "2", "b": "2"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := `data.a: "1" -> "2", data.b: "1" -> "2"`; diff != expected {
+		t.Errorf("expected %s, got %s", expected, diff)
+	}
+}
@@ -0,0 +1,127 @@
+// Package errors classifies errors, so that consumers can implement uniform retry and ignore policies
+// instead of matching error strings.
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// Class is the classification of an error
+type Class string
+
+const (
+	// Unknown errors couldn't be classified
+	Unknown Class = "Unknown"
+	// Transient errors are expected to go away when retrying later, e.g. timeouts or an unavailable API server
+	Transient Class = "Transient"
+	// Conflict errors are caused by concurrent modifications, and can be retried after reading the latest state
+	Conflict Class = "Conflict"
+	// Forbidden errors are caused by missing permissions, retrying won't help without RBAC changes
+	Forbidden Class = "Forbidden"
+	// NotSupported errors are caused by APIs or features which aren't available in the cluster
+	NotSupported Class = "NotSupported"
+	// QuorumRisk errors refuse an action because it would put etcd quorum at risk
+	QuorumRisk Class = "QuorumRisk"
+)
+
+// ClassifiedError is an error with an explicit classification
+type ClassifiedError struct {
+	Class Class
+	Err   error
+}
+
+func (e *ClassifiedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *ClassifiedError) Unwrap() error {
+	return e.Err
+}
+
+// New returns a new error with the given class
+func New(class Class, format string, args ...interface{}) error {
+	return &ClassifiedError{Class: class, Err: fmt.Errorf(format, args...)}
+}
+
+// Wrap classifies err and adds context to its message. It returns nil if err is nil.
+func Wrap(class Class, err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	return &ClassifiedError{Class: class, Err: fmt.Errorf("%s: %w", message, err)}
+}
+
+// WrapAuto adds context to the message of err and keeps its derived classification. It returns nil if err is nil.
+func WrapAuto(err error, message string) error {
+	return Wrap(ClassOf(err), err, message)
+}
+
+// ClassOf returns the class of err. Explicitly classified errors keep their class, API and network errors are
+// classified by their type.
+func ClassOf(err error) Class {
+	if err == nil {
+		return Unknown
+	}
+	var classified *ClassifiedError
+	if errors.As(err, &classified) {
+		return classified.Class
+	}
+
+	switch {
+	case apierrors.IsConflict(err):
+		return Conflict
+	case apierrors.IsForbidden(err), apierrors.IsUnauthorized(err):
+		return Forbidden
+	case meta.IsNoMatchError(err), apierrors.IsMethodNotSupported(err):
+		return NotSupported
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err), apierrors.IsTooManyRequests(err),
+		apierrors.IsServiceUnavailable(err), apierrors.IsInternalError(err), apierrors.IsUnexpectedServerError(err):
+		return Transient
+	case errors.Is(err, context.DeadlineExceeded):
+		return Transient
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return Transient
+	}
+	return Unknown
+}
+
+// IsTransient returns true if err is expected to go away when retrying later
+func IsTransient(err error) bool {
+	return ClassOf(err) == Transient
+}
+
+// IsConflict returns true if err is caused by a concurrent modification
+func IsConflict(err error) bool {
+	return ClassOf(err) == Conflict
+}
+
+// IsForbidden returns true if err is caused by missing permissions
+func IsForbidden(err error) bool {
+	return ClassOf(err) == Forbidden
+}
+
+// IsNotSupported returns true if err is caused by a missing API or feature
+func IsNotSupported(err error) bool {
+	return ClassOf(err) == NotSupported
+}
+
+// IsQuorumRisk returns true if err refuses an action which would put etcd quorum at risk
+func IsQuorumRisk(err error) bool {
+	return ClassOf(err) == QuorumRisk
+}
+
+// IsRetryable returns true if retrying might succeed, which is the case for transient and conflict errors
+func IsRetryable(err error) bool {
+	class := ClassOf(err)
+	return class == Transient || class == Conflict
+}
@@ -0,0 +1,51 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestClassOf(t *testing.T) {
+	resource := schema.GroupResource{Resource: "leases"}
+	tests := []struct {
+		name      string
+		err       error
+		class     Class
+		retryable bool
+	}{
+		{name: "nil", err: nil, class: Unknown},
+		{name: "plain", err: fmt.Errorf("boom"), class: Unknown},
+		{name: "conflict", err: apierrors.NewConflict(resource, "l", fmt.Errorf("changed")), class: Conflict, retryable: true},
+		{name: "forbidden", err: apierrors.NewForbidden(resource, "l", fmt.Errorf("denied")), class: Forbidden},
+		{name: "unauthorized", err: apierrors.NewUnauthorized("no token"), class: Forbidden},
+		{name: "timeout", err: apierrors.NewTimeoutError("slow", 1), class: Transient, retryable: true},
+		{name: "unavailable", err: apierrors.NewServiceUnavailable("down"), class: Transient, retryable: true},
+		{name: "deadline", err: fmt.Errorf("wrapped: %w", context.DeadlineExceeded), class: Transient, retryable: true},
+		{name: "explicit class wins", err: Wrap(QuorumRisk, apierrors.NewServiceUnavailable("down"), "refused"), class: QuorumRisk},
+		{name: "auto keeps class", err: WrapAuto(apierrors.NewConflict(resource, "l", fmt.Errorf("changed")), "context"), class: Conflict, retryable: true},
+		{name: "new", err: New(NotSupported, "no %s", "API"), class: NotSupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if class := ClassOf(tt.err); class != tt.class {
+				t.Errorf("expected class %s, got %s", tt.class, class)
+			}
+			if retryable := IsRetryable(tt.err); retryable != tt.retryable {
+				t.Errorf("expected retryable %t, got %t", tt.retryable, retryable)
+			}
+		})
+	}
+}
+
+func TestWrapNil(t *testing.T) {
+	if err := Wrap(Transient, nil, "context"); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+	if err := WrapAuto(nil, "context"); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
@@ -0,0 +1,279 @@
+package etcd
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	medik8serrors "github.com/medik8s/common/pkg/errors"
+	"github.com/medik8s/common/pkg/tracing"
+)
+
+const (
+	// DefaultCacheTTL is the default time for which an EtcdChecker reuses the listed PDB and guard pods
+	DefaultCacheTTL = 5 * time.Second
+)
+
+// DefaultBackoff is the default backoff for retrying transient errors, it gives up after about 3.5s
+var DefaultBackoff = wait.Backoff{
+	Steps:    4,
+	Duration: 500 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+}
+
+// Options configures an EtcdChecker
+type Options struct {
+	// Namespace of the guard PDB and pods, and of the etcd client certificate, CA bundle and endpoints. Defaults to
+	// EtcdNamespace.
+	Namespace string
+	// PDBName is the name of the guard PDB. If empty, the only PDB in Namespace matching PDBSelector is used,
+	// or the one with one of the WellKnownGuardPDBNames.
+	PDBName string
+	// PDBSelector filters the PDBs in Namespace, if set
+	PDBSelector labels.Selector
+	// GuardPodSelector selects the guard pods in Namespace. Defaults to the selector of the guard PDB.
+	GuardPodSelector labels.Selector
+	// CacheTTL is the time for which the listed PDB and guard pods are reused, defaults to DefaultCacheTTL.
+	// Keep it short, a stale view can allow disruptions which break quorum.
+	CacheTTL time.Duration
+	// StaticPodNamespace is the namespace of the etcd static pods, which are used when no guard PDB exists.
+	// Defaults to StaticPodNamespace.
+	StaticPodNamespace string
+	// StaticPodSelector selects the etcd static pods, defaults to StaticPodLabels
+	StaticPodSelector labels.Selector
+	// MissingPDBPolicy decides what happens when neither a guard PDB nor etcd static pods exist, defaults to
+	// MissingPDBPolicyRefuse
+	MissingPDBPolicy MissingPDBPolicy
+	// Backoff is used for retrying transient errors, e.g. while the API server is disrupted. Defaults to
+	// DefaultBackoff, set Steps to 1 for disabling retries.
+	Backoff wait.Backoff
+	// Recorder emits a Warning event on the target object whenever a disruption is refused, if set
+	Recorder record.EventRecorder
+	// DetectLearners lists the etcd members, so that nodes which only host a learner member can be disrupted even
+	// when the guard PDB allows no disruptions. This needs access to the etcd client certificate.
+	DetectLearners bool
+	// CheckAlarms lists the active etcd alarms. Disrupting nodes with a healthy member is refused while an alarm in
+	// RefusingAlarms is active, and the alarms are reported in the decisions. This needs access to the etcd client
+	// certificate.
+	CheckAlarms bool
+	// MemberTimeout is the timeout for talking to etcd members, defaults to DefaultMemberTimeout
+	MemberTimeout time.Duration
+	// Logger logs decisions and failed checks, defaults to the package logger. Callers can label it with the action
+	// they are about to take, e.g. log.WithValues("action", "fencing").
+	Logger logr.Logger
+}
+
+func (o Options) withDefaults() Options {
+	if o.Namespace == "" {
+		o.Namespace = EtcdNamespace
+	}
+	if o.CacheTTL <= 0 {
+		o.CacheTTL = DefaultCacheTTL
+	}
+	if o.StaticPodNamespace == "" {
+		o.StaticPodNamespace = StaticPodNamespace
+	}
+	if o.StaticPodSelector == nil {
+		o.StaticPodSelector = StaticPodLabels.AsSelector()
+	}
+	if o.Backoff.Steps <= 0 {
+		o.Backoff = DefaultBackoff
+	}
+	if o.MissingPDBPolicy == "" {
+		o.MissingPDBPolicy = MissingPDBPolicyRefuse
+	}
+	if o.MemberTimeout <= 0 {
+		o.MemberTimeout = DefaultMemberTimeout
+	}
+	if o.Logger.GetSink() == nil {
+		o.Logger = log
+	}
+	return o
+}
+
+// EtcdChecker checks etcd disruptions like IsEtcdDisruptionAllowedForNodes, but reuses the listed PDB and guard pods
+// for a short time, so that reconcile storms don't list them over and over again.
+type EtcdChecker struct {
+	client  client.Reader
+	options Options
+
+	lock     sync.Mutex
+	cached   *guardState
+	cachedAt time.Time
+	// generation is incremented by Invalidate, so that states listed before don't end up in the cache
+	generation int
+
+	decisionsLock sync.Mutex
+	lastDecisions map[string]DisruptionDecision
+}
+
+// NewEtcdChecker creates an EtcdChecker. Empty options are replaced by the defaults.
+func NewEtcdChecker(cl client.Reader, options Options) *EtcdChecker {
+	return &EtcdChecker{
+		client:        cl,
+		options:       options.withDefaults(),
+		lastDecisions: make(map[string]DisruptionDecision),
+	}
+}
+
+// IsDisruptionAllowed decides whether the node can be disrupted without risking etcd quorum.
+// Refusals are recorded as events on the node, if a Recorder is configured.
+func (c *EtcdChecker) IsDisruptionAllowed(ctx context.Context, node *corev1.Node) (DisruptionDecision, error) {
+	return c.IsDisruptionAllowedFor(ctx, node, node)
+}
+
+// IsDisruptionAllowedFor decides whether the node can be disrupted without risking etcd quorum.
+// Refusals are recorded as events on target, usually the remediation CR, if a Recorder is configured.
+func (c *EtcdChecker) IsDisruptionAllowedFor(ctx context.Context, node *corev1.Node, target runtime.Object) (DisruptionDecision, error) {
+	decisions, err := c.decide(ctx, []*corev1.Node{node})
+	if err != nil {
+		return errorDecision(node.Name, err), err
+	}
+	decision := decisions[node.Name]
+	if c.options.Recorder != nil {
+		decision.RecordEvent(c.options.Recorder, target)
+	}
+	return decision, nil
+}
+
+// IsDisruptionAllowedForNodes decides for each given node whether it can be disrupted without risking etcd quorum,
+// see IsEtcdDisruptionAllowedForNodes. Transient errors are retried with the configured backoff, if they persist the
+// returned error is classified as transient, see errors.IsTransient. On errors every node is refused with
+// ReasonTransientError or ReasonError. Refusals are recorded as events on the refused nodes, if a Recorder is configured.
+func (c *EtcdChecker) IsDisruptionAllowedForNodes(ctx context.Context, nodes []*corev1.Node) (map[string]DisruptionDecision, error) {
+	decisions, err := c.decide(ctx, nodes)
+	if err != nil {
+		decisions = make(map[string]DisruptionDecision, len(nodes))
+		for _, node := range nodes {
+			decisions[node.Name] = errorDecision(node.Name, err)
+		}
+		return decisions, err
+	}
+	if c.options.Recorder != nil {
+		recorded := make(map[string]bool, len(nodes))
+		for _, node := range nodes {
+			if !recorded[node.Name] {
+				recorded[node.Name] = true
+				decisions[node.Name].RecordEvent(c.options.Recorder, node)
+			}
+		}
+	}
+	return decisions, nil
+}
+
+// decide decides like IsDisruptionAllowedForNodes without recording events, and remembers the decisions
+func (c *EtcdChecker) decide(ctx context.Context, nodes []*corev1.Node) (decisions map[string]DisruptionDecision, err error) {
+	nodeNames := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		nodeNames = append(nodeNames, node.Name)
+	}
+	ctx, span := tracing.Start(ctx, "etcd.IsDisruptionAllowed", attribute.StringSlice("nodes", nodeNames))
+	defer func() { tracing.End(span, err) }()
+
+	state, err := c.getGuardState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	decisions = state.decideAll(c.options.Logger, nodes)
+	refused := 0
+	for _, decision := range decisions {
+		if !decision.Allowed {
+			refused++
+		}
+	}
+	span.SetAttributes(attribute.Int("refused", refused))
+	c.decisionsLock.Lock()
+	defer c.decisionsLock.Unlock()
+	for nodeName, decision := range decisions {
+		c.lastDecisions[nodeName] = decision
+	}
+	return decisions, nil
+}
+
+// LastDecisions returns the latest decision of every node checked so far, sorted by node name
+func (c *EtcdChecker) LastDecisions() []DisruptionDecision {
+	c.decisionsLock.Lock()
+	defer c.decisionsLock.Unlock()
+	decisions := make([]DisruptionDecision, 0, len(c.lastDecisions))
+	for _, decision := range c.lastDecisions {
+		decisions = append(decisions, decision)
+	}
+	sort.Slice(decisions, func(i, j int) bool { return decisions[i].NodeName < decisions[j].NodeName })
+	return decisions
+}
+
+// Invalidate drops the cached PDB and guard pods, e.g. after a node was disrupted
+func (c *EtcdChecker) Invalidate() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.cached = nil
+	c.generation++
+}
+
+// getGuardState returns the cached state, or lists a new one. The lock is only held for reading and swapping the
+// cache, so that concurrent callers don't wait for each other's retries.
+func (c *EtcdChecker) getGuardState(ctx context.Context) (_ *guardState, err error) {
+	ctx, span := tracing.Start(ctx, "etcd.getGuardState")
+	defer func() { tracing.End(span, err) }()
+
+	c.lock.Lock()
+	if c.cached != nil && time.Since(c.cachedAt) < c.options.CacheTTL {
+		cached := c.cached
+		c.lock.Unlock()
+		span.SetAttributes(attribute.Bool("cached", true))
+		return cached, nil
+	}
+	generation := c.generation
+	c.lock.Unlock()
+	span.SetAttributes(attribute.Bool("cached", false))
+
+	var state *guardState
+	isRetryable := func(err error) bool {
+		return ctx.Err() == nil && medik8serrors.IsTransient(err)
+	}
+	err = retry.OnError(c.options.Backoff, isRetryable, func() error {
+		var err error
+		state, err = getGuardState(ctx, c.client, c.options)
+		if err == nil {
+			err = state.addMembers(ctx, c.client, c.options)
+		}
+		if err != nil && isRetryable(err) {
+			c.options.Logger.Info("etcd disruption check failed, retrying", "error", err.Error())
+		}
+		return err
+	})
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err != nil {
+		c.cached = nil
+		c.options.Logger.Error(err, "etcd disruption check failed", "transient", medik8serrors.IsTransient(err))
+		return nil, medik8serrors.WrapAuto(err, "failed to check etcd disruption")
+	}
+	if c.generation == generation {
+		c.cached = state
+		c.cachedAt = time.Now()
+	}
+	return state, nil
+}
+
+// errorDecision refuses the disruption of the node because the check failed
+func errorDecision(nodeName string, err error) DisruptionDecision {
+	decision := DisruptionDecision{NodeName: nodeName, Reason: ReasonError}
+	if medik8serrors.IsTransient(err) {
+		decision.Reason = ReasonTransientError
+	}
+	return decision
+}
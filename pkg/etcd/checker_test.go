@@ -0,0 +1,148 @@
+package etcd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestEtcdCheckerCache(t *testing.T) {
+	ctx := context.Background()
+	node := controlPlaneNode("master-0", true)
+	pdb := guardPDB("etcd-guard-pdb", 1)
+	cl := newClient(pdb, guardPod("master-0", true))
+	checker := NewEtcdChecker(cl, Options{CacheTTL: time.Hour})
+
+	decision, err := checker.IsDisruptionAllowed(ctx, node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !decision.Allowed || decision.Reason != ReasonDisruptionsAllowed {
+		t.Fatalf("expected allowed with reason %s, got %+v", ReasonDisruptionsAllowed, decision)
+	}
+
+	if err := cl.Delete(ctx, pdb); err != nil {
+		t.Fatalf("failed to delete PDB: %v", err)
+	}
+	decision, err = checker.IsDisruptionAllowed(ctx, node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !decision.Allowed || decision.Reason != ReasonDisruptionsAllowed {
+		t.Errorf("expected the cached decision, got %+v", decision)
+	}
+
+	checker.Invalidate()
+	decision, err = checker.IsDisruptionAllowed(ctx, node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decision.Allowed || decision.Reason != ReasonNoPDB {
+		t.Errorf("expected refused with reason %s after Invalidate, got %+v", ReasonNoPDB, decision)
+	}
+}
+
+func TestNewEtcdCheckerDefaults(t *testing.T) {
+	checker := NewEtcdChecker(newClient(), Options{})
+	if checker.options.Namespace != EtcdNamespace {
+		t.Errorf("expected namespace %s, got %s", EtcdNamespace, checker.options.Namespace)
+	}
+	if checker.options.CacheTTL != DefaultCacheTTL {
+		t.Errorf("expected cache TTL %s, got %s", DefaultCacheTTL, checker.options.CacheTTL)
+	}
+	if checker.options.Logger.GetSink() == nil {
+		t.Errorf("expected the package logger as default")
+	}
+}
+
+func TestEtcdCheckerLastDecisions(t *testing.T) {
+	ctx := context.Background()
+	master0, master1 := controlPlaneNode("master-0", true), controlPlaneNode("master-1", true)
+	checker := NewEtcdChecker(newClient(guardPDB("etcd-guard-pdb", 1), guardPod("master-0", true), guardPod("master-1", true)), Options{})
+
+	if _, err := checker.IsDisruptionAllowed(ctx, master1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := checker.IsDisruptionAllowed(ctx, master0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decisions := checker.LastDecisions()
+	if len(decisions) != 2 || decisions[0].NodeName != "master-0" || decisions[1].NodeName != "master-1" {
+		t.Errorf("expected the decisions of master-0 and master-1, got %+v", decisions)
+	}
+}
+
+func TestEtcdCheckerLogger(t *testing.T) {
+	var logged []string
+	logger := funcr.New(func(prefix, args string) { logged = append(logged, args) }, funcr.Options{})
+	checker := NewEtcdChecker(newClient(guardPDB("etcd-guard-pdb", 1), guardPod("logged-master", true)), Options{Logger: logger})
+
+	if _, err := checker.IsDisruptionAllowed(context.Background(), controlPlaneNode("logged-master", true)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(logged) != 1 || !strings.Contains(logged[0], "etcd disruption decision") {
+		t.Errorf("expected the decision to be logged with the given logger, got %v", logged)
+	}
+}
+
+func TestEtcdCheckerBatchEvents(t *testing.T) {
+	master0, master1 := controlPlaneNode("master-0", true), controlPlaneNode("master-1", true)
+	recorder := record.NewFakeRecorder(10)
+	cl := newClient(guardPDB("etcd-guard-pdb", 1), guardPod("master-0", true), guardPod("master-1", true))
+	checker := NewEtcdChecker(cl, Options{Recorder: recorder})
+
+	if _, err := checker.IsDisruptionAllowedForNodes(context.Background(), []*corev1.Node{master0, master1, master1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	close(recorder.Events)
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	if len(events) != 1 || !strings.Contains(events[0], EventReasonBlockedByEtcdQuorum) || !strings.Contains(events[0], "master-1") {
+		t.Errorf("expected a single refusal event for master-1, got %v", events)
+	}
+}
+
+func TestEtcdCheckerBatchErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		reason Reason
+	}{
+		{name: "transient error", err: apierrors.NewServiceUnavailable("etcd is down"), reason: ReasonTransientError},
+		{name: "other error", err: apierrors.NewForbidden(schema.GroupResource{Resource: "poddisruptionbudgets"}, "", nil), reason: ReasonError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+				List: func(_ context.Context, _ client.WithWatch, _ client.ObjectList, _ ...client.ListOption) error {
+					return tt.err
+				},
+			}).Build()
+			checker := NewEtcdChecker(cl, Options{Backoff: wait.Backoff{Steps: 1}})
+
+			nodes := []*corev1.Node{controlPlaneNode("master-0", true), controlPlaneNode("master-1", true)}
+			decisions, err := checker.IsDisruptionAllowedForNodes(context.Background(), nodes)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			for _, node := range nodes {
+				if decision := decisions[node.Name]; decision.Allowed || decision.Reason != tt.reason {
+					t.Errorf("%s: expected refused with reason %s, got %+v", node.Name, tt.reason, decision)
+				}
+			}
+		})
+	}
+}
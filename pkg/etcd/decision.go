@@ -0,0 +1,91 @@
+package etcd
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionTypeEtcdDisruptionAllowed is the type of the condition returned by DisruptionDecision.Condition
+	ConditionTypeEtcdDisruptionAllowed = "EtcdDisruptionAllowed"
+)
+
+// Reason explains a DisruptionDecision
+type Reason string
+
+const (
+	// ReasonNotControlPlane means the node doesn't run an etcd member
+	ReasonNotControlPlane Reason = "NotControlPlane"
+	// ReasonDisruptionsAllowed means the guard PDB allows more disruptions
+	ReasonDisruptionsAllowed Reason = "DisruptionsAllowed"
+	// ReasonNodeAlreadyDisrupted means the node's guard pod is already not ready, so disrupting the node doesn't
+	// reduce the number of healthy etcd members further
+	ReasonNodeAlreadyDisrupted Reason = "NodeAlreadyDisrupted"
+	// ReasonQuorumAtRisk means the guard PDB doesn't allow more disruptions
+	ReasonQuorumAtRisk Reason = "QuorumAtRisk"
+	// ReasonNoGuardPod means no guard pod was found on the node, while the PDB doesn't allow more disruptions
+	ReasonNoGuardPod Reason = "NoGuardPod"
+	// ReasonNoPDB means no guard PDB was found
+	ReasonNoPDB Reason = "NoPDB"
+	// ReasonTransientError means the check failed with a transient error, even after retries, so the disruption
+	// should be checked again later
+	ReasonTransientError Reason = "TransientError"
+	// ReasonError means the check failed with a non transient error
+	ReasonError Reason = "Error"
+	// ReasonMultiplePDBs means more than one PDB was found and none of them could be selected as the guard PDB
+	ReasonMultiplePDBs Reason = "MultiplePDBs"
+)
+
+// DisruptionDecision is the outcome of an etcd disruption check for a node
+type DisruptionDecision struct {
+	NodeName string
+	Allowed  bool
+	Reason   Reason
+	// PDB is the namespace/name of the examined guard PDB, if any
+	PDB string
+	// DisruptionsAllowed of the examined guard PDB
+	DisruptionsAllowed int32
+	// GuardPod is the namespace/name of the examined guard pod, or of the etcd static pod on clusters without guard
+	// PDB, if any
+	GuardPod string
+	// Alarms are the active etcd alarms, only set when the etcd members were queried
+	Alarms []string
+	// MemberRole is the role of the node's etcd member, only set when the etcd members were queried
+	MemberRole MemberRole
+}
+
+// Message describes the decision
+func (d DisruptionDecision) Message() string {
+	verb := "refused"
+	if d.Allowed {
+		verb = "allowed"
+	}
+	message := fmt.Sprintf("Disruption of node %s %s by etcd quorum protection: %s", d.NodeName, verb, d.Reason)
+	if d.PDB != "" {
+		message += fmt.Sprintf(", guard PDB %s allows %d disruptions", d.PDB, d.DisruptionsAllowed)
+	}
+	if d.GuardPod != "" {
+		message += fmt.Sprintf(", guard pod %s", d.GuardPod)
+	}
+	if len(d.Alarms) > 0 {
+		message += fmt.Sprintf(", alarms %v", d.Alarms)
+	}
+	return message
+}
+
+// Condition returns a status condition for the decision, so that remediation CRs can surface why a disruption was
+// refused. The Reason of the condition is the Reason of the decision.
+func (d DisruptionDecision) Condition(observedGeneration int64) metav1.Condition {
+	status := metav1.ConditionFalse
+	if d.Allowed {
+		status = metav1.ConditionTrue
+	}
+	return metav1.Condition{
+		Type:               ConditionTypeEtcdDisruptionAllowed,
+		Status:             status,
+		ObservedGeneration: observedGeneration,
+		Reason:             string(d.Reason),
+		Message:            d.Message(),
+	}
+}
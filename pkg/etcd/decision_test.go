@@ -0,0 +1,42 @@
+package etcd
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDecisionCondition(t *testing.T) {
+	tests := []struct {
+		name     string
+		decision DisruptionDecision
+		status   metav1.ConditionStatus
+		contains string
+	}{
+		{
+			name:     "allowed",
+			decision: DisruptionDecision{NodeName: "master-0", Allowed: true, Reason: ReasonDisruptionsAllowed, PDB: "openshift-etcd/etcd-guard-pdb", DisruptionsAllowed: 1},
+			status:   metav1.ConditionTrue,
+			contains: "guard PDB openshift-etcd/etcd-guard-pdb allows 1 disruptions",
+		},
+		{
+			name:     "refused",
+			decision: DisruptionDecision{NodeName: "master-0", Reason: ReasonQuorumAtRisk, GuardPod: "openshift-etcd/etcd-guard-master-0"},
+			status:   metav1.ConditionFalse,
+			contains: "refused by etcd quorum protection: QuorumAtRisk",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition := tt.decision.Condition(3)
+			if condition.Type != ConditionTypeEtcdDisruptionAllowed || condition.Status != tt.status ||
+				condition.Reason != string(tt.decision.Reason) || condition.ObservedGeneration != 3 {
+				t.Errorf("unexpected condition %+v", condition)
+			}
+			if !strings.Contains(condition.Message, tt.contains) {
+				t.Errorf("expected message to contain %q, got %q", tt.contains, condition.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+// Package etcd checks whether disrupting control plane nodes is safe for etcd quorum.
+// On OpenShift each control plane node runs an etcd guard pod, which is covered by a PodDisruptionBudget.
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/common/pkg/logging"
+	"github.com/medik8s/common/pkg/nodes"
+)
+
+const (
+	// EtcdNamespace is the namespace of the etcd guard pods and their PDB on OpenShift
+	EtcdNamespace = "openshift-etcd"
+)
+
+// WellKnownGuardPDBNames are the names of the guard PDB, used when more than one PDB exists
+var WellKnownGuardPDBNames = []string{"etcd-guard-pdb"}
+
+var (
+	log = ctrl.Log.WithName("etcd")
+	// decisions are made on every reconcile, only changed decisions are logged right away
+	decisionLog = logging.Every(5 * time.Minute)
+)
+
+// IsEtcdDisruptionAllowedForNodes decides for each given node whether it can be disrupted without risking etcd
+// quorum. All decisions are computed from a single listing of the guard PDB and pods, so they are consistent with
+// each other. The nodes share the disruptions allowed by the guard PDB, or tolerated by the etcd static pods or
+// members: they are decided in the order of their names, and once the budget is used up, further nodes hosting a
+// healthy member are refused with ReasonQuorumAtRisk. Empty options are replaced by the defaults, callers which check
+// repeatedly should use an EtcdChecker instead.
+func IsEtcdDisruptionAllowedForNodes(ctx context.Context, cl client.Reader, nodes []*corev1.Node, options Options) (map[string]DisruptionDecision, error) {
+	return NewEtcdChecker(cl, options).IsDisruptionAllowedForNodes(ctx, nodes)
+}
+
+// IsEtcdDisruptionAllowed returns true if the node can be disrupted without risking etcd quorum.
+// The decision is logged with the given logger instead of options.Logger, so callers can label it with the action
+// they are about to take, e.g. log.WithValues("action", "fencing").
+func IsEtcdDisruptionAllowed(ctx context.Context, cl client.Reader, log logr.Logger, node *corev1.Node, options Options) (bool, error) {
+	options.Logger = log
+	decision, err := NewEtcdChecker(cl, options).IsDisruptionAllowed(ctx, node)
+	if err != nil {
+		return false, err
+	}
+	return decision.Allowed, nil
+}
+
+// guardState is a snapshot of the guard PDB and its pods
+type guardState struct {
+	// pdb is nil if no unique guard PDB was found, noPDBReason tells why
+	pdb         *policyv1.PodDisruptionBudget
+	noPDBReason Reason
+	guardPods   []corev1.Pod
+
+	// the static pods and control plane nodes are only set if no guard PDB exists, for the static pod fallback or
+	// the FallbackToMemberCount policy
+	staticPods             []corev1.Pod
+	controlPlaneNodes      int
+	readyControlPlaneNodes int
+	readyControlPlane      map[string]bool
+	missingPDBPolicy       MissingPDBPolicy
+
+	// members is only set if the etcd members were queried for learner detection
+	members *MemberHealthReport
+	// alarms is only set if the etcd alarms were queried
+	alarms []Alarm
+}
+
+func getGuardState(ctx context.Context, cl client.Reader, options Options) (*guardState, error) {
+	namespace := options.Namespace
+	pdbs := &policyv1.PodDisruptionBudgetList{}
+	if err := cl.List(ctx, pdbs, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list PDBs in %s: %w", namespace, err)
+	}
+	pdb, noPDBReason := selectGuardPDB(pdbs.Items, options)
+	if pdb == nil {
+		state := &guardState{noPDBReason: noPDBReason, missingPDBPolicy: options.MissingPDBPolicy}
+		if noPDBReason == ReasonNoPDB {
+			// not OpenShift, or the guard isn't deployed yet
+			if err := state.addStaticPods(ctx, cl, options); err != nil {
+				return nil, err
+			}
+			if len(state.staticPods) == 0 && options.MissingPDBPolicy == MissingPDBPolicyFallbackToMemberCount {
+				if err := state.addControlPlaneNodes(ctx, cl); err != nil {
+					return nil, err
+				}
+			}
+		}
+		recordState(state)
+		return state, nil
+	}
+
+	state := &guardState{pdb: pdb}
+	selector := options.GuardPodSelector
+	if selector == nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(state.pdb.Spec.Selector); err != nil {
+			return nil, fmt.Errorf("invalid selector of PDB %s/%s: %w", state.pdb.Namespace, state.pdb.Name, err)
+		}
+	}
+	pods := &corev1.PodList{}
+	if err := cl.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("failed to list guard pods in %s: %w", namespace, err)
+	}
+	state.guardPods = pods.Items
+	recordState(state)
+	return state, nil
+}
+
+// addMembers queries the etcd members, if options ask for learner detection or alarms
+func (s *guardState) addMembers(ctx context.Context, cl client.Reader, options Options) error {
+	if !options.DetectLearners && !options.CheckAlarms {
+		return nil
+	}
+	report, err := GetMemberHealth(ctx, cl, options)
+	if err != nil {
+		return err
+	}
+	if options.DetectLearners {
+		s.members = report
+	}
+	if options.CheckAlarms {
+		s.alarms = report.Alarms
+	}
+	return nil
+}
+
+// selectGuardPDB returns the guard PDB, or the reason why there is no unique one.
+// An explicit name wins, otherwise the candidates are filtered by the label selector. If more than one candidate
+// remains, a candidate with a well-known name is used.
+func selectGuardPDB(pdbs []policyv1.PodDisruptionBudget, options Options) (*policyv1.PodDisruptionBudget, Reason) {
+	if options.PDBName != "" {
+		for i := range pdbs {
+			if pdbs[i].Name == options.PDBName {
+				return &pdbs[i], ""
+			}
+		}
+		return nil, ReasonNoPDB
+	}
+
+	var candidates []*policyv1.PodDisruptionBudget
+	for i := range pdbs {
+		if options.PDBSelector == nil || options.PDBSelector.Matches(labels.Set(pdbs[i].Labels)) {
+			candidates = append(candidates, &pdbs[i])
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, ReasonNoPDB
+	case 1:
+		return candidates[0], ""
+	}
+
+	var wellKnown []*policyv1.PodDisruptionBudget
+	for _, candidate := range candidates {
+		for _, name := range WellKnownGuardPDBNames {
+			if candidate.Name == name {
+				wellKnown = append(wellKnown, candidate)
+			}
+		}
+	}
+	if len(wellKnown) == 1 {
+		return wellKnown[0], ""
+	}
+	return nil, ReasonMultiplePDBs
+}
+
+func (s *guardState) decideAll(log logr.Logger, nodes []*corev1.Node) map[string]DisruptionDecision {
+	sorted := append([]*corev1.Node(nil), nodes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	budget := s.disruptionBudget()
+	decisions := make(map[string]DisruptionDecision, len(nodes))
+	for _, node := range sorted {
+		if _, decided := decisions[node.Name]; decided {
+			continue
+		}
+		decision := s.decide(node)
+		s.applyMemberRole(&decision)
+		s.applyAlarms(node, &decision)
+		if decision.Allowed && s.consumesBudget(node, decision) {
+			if budget > 0 {
+				budget--
+			} else {
+				decision.Allowed = false
+				decision.Reason = ReasonQuorumAtRisk
+			}
+		}
+		recordDecision(decision)
+		key := node.Name + "/" + string(decision.Reason)
+		if decision.Allowed && decision.Reason == ReasonNoPDB {
+			decisionLog.Info(log, "warning/"+key, "Warning: allowing disruption without etcd guard PDB", "node", node.Name)
+		}
+		decisionLog.Info(log, key, "etcd disruption decision", "node", node.Name, "allowed", decision.Allowed, "reason", decision.Reason)
+		decisions[node.Name] = decision
+	}
+	return decisions
+}
+
+// disruptionBudget returns how many nodes hosting a healthy member can be disrupted together
+func (s *guardState) disruptionBudget() int {
+	switch {
+	case s.pdb != nil:
+		return int(s.pdb.Status.DisruptionsAllowed)
+	case len(s.staticPods) > 0:
+		return s.staticPodDisruptionsTolerable()
+	case s.missingPDBPolicy == MissingPDBPolicyFallbackToMemberCount:
+		_, tolerable := ComputeQuorum(s.controlPlaneNodes, s.readyControlPlaneNodes)
+		return tolerable
+	}
+	return 0
+}
+
+// consumesBudget returns true if disrupting the node reduces the number of healthy members. Nodes which are already
+// disrupted, host no member or only a learner don't count.
+func (s *guardState) consumesBudget(node *corev1.Node, decision DisruptionDecision) bool {
+	if decision.MemberRole == MemberRoleLearner {
+		return false
+	}
+	switch decision.Reason {
+	case ReasonMembersHealthy:
+		return true
+	case ReasonDisruptionsAllowed:
+		guardPod := s.guardPodOf(node.Name)
+		return guardPod == nil || isPodReady(guardPod)
+	}
+	return false
+}
+
+func (s *guardState) decide(node *corev1.Node) DisruptionDecision {
+	decision := DisruptionDecision{NodeName: node.Name}
+	if !nodes.IsControlPlane(node) {
+		decision.Allowed = true
+		decision.Reason = ReasonNotControlPlane
+		return decision
+	}
+	if s.pdb == nil {
+		if len(s.staticPods) > 0 {
+			return s.decideByStaticPods(node)
+		}
+		if s.noPDBReason == ReasonNoPDB {
+			return s.decideByMissingPDBPolicy(node)
+		}
+		decision.Reason = s.noPDBReason
+		return decision
+	}
+	decision.PDB = s.pdb.Namespace + "/" + s.pdb.Name
+	decision.DisruptionsAllowed = s.pdb.Status.DisruptionsAllowed
+	if s.pdb.Status.DisruptionsAllowed >= 1 {
+		decision.Allowed = true
+		decision.Reason = ReasonDisruptionsAllowed
+		return decision
+	}
+
+	// no disruptions allowed, which is fine if this node's guard pod is already disrupted
+	guardPod := s.guardPodOf(node.Name)
+	if guardPod == nil {
+		decision.Reason = ReasonNoGuardPod
+		return decision
+	}
+	decision.GuardPod = guardPod.Namespace + "/" + guardPod.Name
+	if !isPodReady(guardPod) {
+		decision.Allowed = true
+		decision.Reason = ReasonNodeAlreadyDisrupted
+		return decision
+	}
+	decision.Reason = ReasonQuorumAtRisk
+	return decision
+}
+
+// applyMemberRole sets the member role of the decision, and allows disrupting nodes which only host a learner member,
+// since learners don't count for quorum. Learners are allowed with ReasonLearner even when the PDB allows the
+// disruption anyway, so that they don't use up the budget of a batch.
+func (s *guardState) applyMemberRole(decision *DisruptionDecision) {
+	if s.members == nil {
+		return
+	}
+	decision.MemberRole = s.members.RoleOf(decision.NodeName)
+	if decision.MemberRole == MemberRoleLearner {
+		decision.Allowed = true
+		decision.Reason = ReasonLearner
+	}
+}
+
+// applyAlarms adds the active alarms to the decision, and refuses disrupting a node with a healthy member while an
+// alarm in RefusingAlarms is active
+func (s *guardState) applyAlarms(node *corev1.Node, decision *DisruptionDecision) {
+	var refusing bool
+	decision.Alarms, refusing = alarmTypes(s.alarms)
+	if refusing && decision.Allowed && (s.consumesBudget(node, *decision) || decision.Reason == ReasonNoPDB) {
+		decision.Allowed = false
+		decision.Reason = ReasonAlarmActive
+	}
+}
+
+func (s *guardState) guardPodOf(nodeName string) *corev1.Pod {
+	for i := range s.guardPods {
+		if s.guardPods[i].Spec.NodeName == nodeName {
+			return &s.guardPods[i]
+		}
+	}
+	return nil
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func countReady(pods []corev1.Pod) int {
+	ready := 0
+	for i := range pods {
+		if isPodReady(&pods[i]) {
+			ready++
+		}
+	}
+	return ready
+}
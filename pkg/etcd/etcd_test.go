@@ -0,0 +1,353 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/medik8s/common/pkg/nodes"
+)
+
+var guardLabels = map[string]string{"app": "guard"}
+
+func controlPlaneNode(name string, ready bool) *corev1.Node {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{nodes.ControlPlaneRoleLabel: ""}},
+		Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}}},
+	}
+}
+
+func workerNode(name string) *corev1.Node {
+	return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{nodes.WorkerRoleLabel: ""}}}
+}
+
+func guardPDB(name string, disruptionsAllowed int32) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Namespace: EtcdNamespace, Name: name},
+		Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: guardLabels}},
+		Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: disruptionsAllowed},
+	}
+}
+
+func pod(namespace, name, nodeName string, labels map[string]string, ready bool) *corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+		Spec:       corev1.PodSpec{NodeName: nodeName},
+		Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+	}
+}
+
+func guardPod(nodeName string, ready bool) *corev1.Pod {
+	return pod(EtcdNamespace, "etcd-guard-"+nodeName, nodeName, guardLabels, ready)
+}
+
+func newClient(objs ...client.Object) client.Client {
+	return fake.NewClientBuilder().WithObjects(objs...).Build()
+}
+
+func TestIsEtcdDisruptionAllowedForNodes(t *testing.T) {
+	master0, master1 := controlPlaneNode("master-0", true), controlPlaneNode("master-1", true)
+	tests := []struct {
+		name     string
+		objs     []client.Object
+		node     *corev1.Node
+		allowed  bool
+		reason   Reason
+		guardPod string
+	}{
+		{
+			name:    "worker node",
+			node:    workerNode("worker-0"),
+			allowed: true,
+			reason:  ReasonNotControlPlane,
+		},
+		{
+			name:   "no PDB",
+			node:   master0,
+			reason: ReasonNoPDB,
+		},
+		{
+			name:   "ambiguous PDBs",
+			objs:   []client.Object{guardPDB("a", 1), guardPDB("b", 1)},
+			node:   master0,
+			reason: ReasonMultiplePDBs,
+		},
+		{
+			name:    "disruption allowed",
+			objs:    []client.Object{guardPDB("etcd-guard-pdb", 1), guardPod("master-0", true)},
+			node:    master0,
+			allowed: true,
+			reason:  ReasonDisruptionsAllowed,
+		},
+		{
+			name:     "no disruption allowed",
+			objs:     []client.Object{guardPDB("etcd-guard-pdb", 0), guardPod("master-0", true), guardPod("master-1", false)},
+			node:     master0,
+			reason:   ReasonQuorumAtRisk,
+			guardPod: EtcdNamespace + "/etcd-guard-master-0",
+		},
+		{
+			name:     "node already disrupted",
+			objs:     []client.Object{guardPDB("etcd-guard-pdb", 0), guardPod("master-0", true), guardPod("master-1", false)},
+			node:     master1,
+			allowed:  true,
+			reason:   ReasonNodeAlreadyDisrupted,
+			guardPod: EtcdNamespace + "/etcd-guard-master-1",
+		},
+		{
+			name:   "no guard pod",
+			objs:   []client.Object{guardPDB("etcd-guard-pdb", 0), guardPod("master-0", true)},
+			node:   master1,
+			reason: ReasonNoGuardPod,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decisions, err := IsEtcdDisruptionAllowedForNodes(context.Background(), newClient(tt.objs...), []*corev1.Node{tt.node}, Options{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			decision, exists := decisions[tt.node.Name]
+			if !exists {
+				t.Fatalf("no decision for node %s", tt.node.Name)
+			}
+			if decision.Allowed != tt.allowed || decision.Reason != tt.reason {
+				t.Errorf("expected allowed %t with reason %s, got %t with reason %s", tt.allowed, tt.reason, decision.Allowed, decision.Reason)
+			}
+			if decision.GuardPod != tt.guardPod {
+				t.Errorf("expected guard pod %q, got %q", tt.guardPod, decision.GuardPod)
+			}
+		})
+	}
+}
+
+func TestIsEtcdDisruptionAllowedForNodesSharesBudget(t *testing.T) {
+	master0, master1, master2, master3 := controlPlaneNode("master-0", true), controlPlaneNode("master-1", true),
+		controlPlaneNode("master-2", true), controlPlaneNode("master-3", true)
+	tests := []struct {
+		name     string
+		objs     []client.Object
+		nodes    []*corev1.Node
+		expected map[string]Reason
+	}{
+		{
+			name:  "guard PDB budget is shared in node name order",
+			objs:  []client.Object{guardPDB("etcd-guard-pdb", 1), guardPod("master-0", true), guardPod("master-1", true), guardPod("master-2", true)},
+			nodes: []*corev1.Node{master2, master0, master1},
+			expected: map[string]Reason{
+				"master-0": ReasonDisruptionsAllowed,
+				"master-1": ReasonQuorumAtRisk,
+				"master-2": ReasonQuorumAtRisk,
+			},
+		},
+		{
+			name:  "already disrupted nodes don't use the budget",
+			objs:  []client.Object{guardPDB("etcd-guard-pdb", 1), guardPod("master-0", false), guardPod("master-1", true), guardPod("master-2", true)},
+			nodes: []*corev1.Node{master0, master1, master2},
+			expected: map[string]Reason{
+				"master-0": ReasonDisruptionsAllowed,
+				"master-1": ReasonDisruptionsAllowed,
+				"master-2": ReasonQuorumAtRisk,
+			},
+		},
+		{
+			name: "static pod budget is shared",
+			objs: []client.Object{master0, master1, master2, master3, staticPod("master-0", true), staticPod("master-1", true),
+				staticPod("master-2", true), staticPod("master-3", true)},
+			nodes: []*corev1.Node{master0, master1, master2},
+			expected: map[string]Reason{
+				"master-0": ReasonMembersHealthy,
+				"master-1": ReasonQuorumAtRisk,
+				"master-2": ReasonQuorumAtRisk,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decisions, err := IsEtcdDisruptionAllowedForNodes(context.Background(), newClient(tt.objs...), tt.nodes, Options{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(decisions) != len(tt.expected) {
+				t.Fatalf("expected %d decisions, got %d", len(tt.expected), len(decisions))
+			}
+			for nodeName, reason := range tt.expected {
+				decision := decisions[nodeName]
+				allowed := reason != ReasonQuorumAtRisk
+				if decision.Allowed != allowed || decision.Reason != reason {
+					t.Errorf("%s: expected allowed %t with reason %s, got %t with reason %s", nodeName, allowed, reason, decision.Allowed, decision.Reason)
+				}
+			}
+		})
+	}
+}
+
+func TestIsEtcdDisruptionAllowedHonoursOptions(t *testing.T) {
+	master0 := controlPlaneNode("master-0", true)
+	pdb, guard := guardPDB("guard", 1), guardPod("master-0", true)
+	pdb.Namespace, guard.Namespace = "etcd", "etcd"
+	cl := newClient(pdb, guard)
+
+	allowed, err := IsEtcdDisruptionAllowed(context.Background(), cl, log, master0, Options{})
+	if err != nil || allowed {
+		t.Errorf("expected a refusal in the default namespace, got %t, %v", allowed, err)
+	}
+	allowed, err = IsEtcdDisruptionAllowed(context.Background(), cl, log, master0, Options{Namespace: "etcd", PDBName: "guard"})
+	if err != nil || !allowed {
+		t.Errorf("expected the disruption to be allowed in the configured namespace, got %t, %v", allowed, err)
+	}
+}
+
+func TestGuardPodSelector(t *testing.T) {
+	master0 := controlPlaneNode("master-0", true)
+	custom := pod(EtcdNamespace, "custom-guard-master-0", "master-0", map[string]string{"app": "custom-guard"}, true)
+	cl := newClient(guardPDB("etcd-guard-pdb", 0), custom)
+
+	tests := []struct {
+		name     string
+		options  Options
+		reason   Reason
+		guardPod string
+	}{
+		{name: "selector of the PDB", reason: ReasonNoGuardPod},
+		{
+			name:     "configured selector",
+			options:  Options{GuardPodSelector: labels.SelectorFromSet(labels.Set{"app": "custom-guard"})},
+			reason:   ReasonQuorumAtRisk,
+			guardPod: EtcdNamespace + "/custom-guard-master-0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decisions, err := IsEtcdDisruptionAllowedForNodes(context.Background(), cl, []*corev1.Node{master0}, tt.options)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if decision := decisions[master0.Name]; decision.Reason != tt.reason || decision.GuardPod != tt.guardPod {
+				t.Errorf("expected reason %s with guard pod %q, got %s with %q", tt.reason, tt.guardPod, decision.Reason, decision.GuardPod)
+			}
+		})
+	}
+}
+
+func TestDecideAllWithAlarms(t *testing.T) {
+	tests := []struct {
+		name    string
+		alarms  []Alarm
+		node    string
+		allowed bool
+		reason  Reason
+	}{
+		{
+			name:    "no alarm",
+			node:    "master-0",
+			allowed: true,
+			reason:  ReasonDisruptionsAllowed,
+		},
+		{
+			name:   "refusing alarm",
+			alarms: []Alarm{{MemberID: 1, Type: "NOSPACE"}},
+			node:   "master-0",
+			reason: ReasonAlarmActive,
+		},
+		{
+			name:    "other alarm",
+			alarms:  []Alarm{{MemberID: 1, Type: "NONE"}},
+			node:    "master-0",
+			allowed: true,
+			reason:  ReasonDisruptionsAllowed,
+		},
+		{
+			name:    "refusing alarm and node already disrupted",
+			alarms:  []Alarm{{MemberID: 1, Type: "CORRUPT"}},
+			node:    "master-1",
+			allowed: true,
+			reason:  ReasonDisruptionsAllowed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := newClient(guardPDB("etcd-guard-pdb", 1), guardPod("master-0", true), guardPod("master-1", false))
+			state, err := getGuardState(context.Background(), cl, Options{}.withDefaults())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			state.alarms = tt.alarms
+			decision := state.decideAll(log, []*corev1.Node{controlPlaneNode(tt.node, true)})[tt.node]
+			if decision.Allowed != tt.allowed || decision.Reason != tt.reason {
+				t.Errorf("expected allowed %t with reason %s, got %t with reason %s", tt.allowed, tt.reason, decision.Allowed, decision.Reason)
+			}
+			if len(decision.Alarms) != len(tt.alarms) {
+				t.Errorf("expected alarms %v, got %v", tt.alarms, decision.Alarms)
+			}
+		})
+	}
+}
+
+func TestDecideAllWithLearner(t *testing.T) {
+	members := &MemberHealthReport{Members: []MemberHealth{
+		{ID: 1, Name: "master-0", Healthy: true, IsLearner: true},
+		{ID: 2, Name: "master-1", Healthy: true, IsLeader: true},
+		{ID: 3, Name: "master-2", Healthy: true},
+	}}
+	tests := []struct {
+		name               string
+		disruptionsAllowed int32
+		expected           map[string]Reason
+	}{
+		{
+			name:               "learner doesn't use the budget",
+			disruptionsAllowed: 1,
+			expected: map[string]Reason{
+				"master-0": ReasonLearner,
+				"master-1": ReasonDisruptionsAllowed,
+				"master-2": ReasonQuorumAtRisk,
+			},
+		},
+		{
+			name: "learner is allowed without budget",
+			expected: map[string]Reason{
+				"master-0": ReasonLearner,
+				"master-1": ReasonQuorumAtRisk,
+				"master-2": ReasonQuorumAtRisk,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := newClient(guardPDB("etcd-guard-pdb", tt.disruptionsAllowed), guardPod("master-0", true),
+				guardPod("master-1", true), guardPod("master-2", true))
+			state, err := getGuardState(context.Background(), cl, Options{}.withDefaults())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			state.members = members
+			decisions := state.decideAll(log, []*corev1.Node{controlPlaneNode("master-2", true),
+				controlPlaneNode("master-1", true), controlPlaneNode("master-0", true)})
+			for nodeName, reason := range tt.expected {
+				decision := decisions[nodeName]
+				allowed := reason != ReasonQuorumAtRisk
+				if decision.Allowed != allowed || decision.Reason != reason {
+					t.Errorf("%s: expected allowed %t with reason %s, got %t with reason %s", nodeName, allowed, reason, decision.Allowed, decision.Reason)
+				}
+			}
+			if role := decisions["master-0"].MemberRole; role != MemberRoleLearner {
+				t.Errorf("expected member role %s, got %s", MemberRoleLearner, role)
+			}
+		})
+	}
+}
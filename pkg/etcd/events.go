@@ -0,0 +1,20 @@
+package etcd
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// EventReasonBlockedByEtcdQuorum is the reason of the event emitted when a disruption is refused
+	EventReasonBlockedByEtcdQuorum = "RemediationBlockedByEtcdQuorum"
+)
+
+// RecordEvent emits a Warning event on obj, usually the remediation CR or the node, if the disruption was refused
+func (d DisruptionDecision) RecordEvent(recorder record.EventRecorder, obj runtime.Object) {
+	if d.Allowed {
+		return
+	}
+	recorder.Event(obj, corev1.EventTypeWarning, EventReasonBlockedByEtcdQuorum, d.Message())
+}
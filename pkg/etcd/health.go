@@ -0,0 +1,90 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/common/pkg/nodes"
+)
+
+// ControlPlaneHealth is a snapshot of the control plane health
+type ControlPlaneHealth struct {
+	TotalNodes int
+	ReadyNodes int
+	Nodes      []NodeHealth
+	// PDB is the namespace/name of the guard PDB, empty if there is none
+	PDB string
+	// DisruptionsAllowed of the guard PDB, or the tolerable disruptions derived from the etcd static pods on
+	// clusters without guard PDB
+	DisruptionsAllowed int32
+}
+
+// NodeHealth is the health of a single control plane node
+type NodeHealth struct {
+	Name  string
+	Ready bool
+	// GuardPod is the namespace/name of the node's guard pod, or of its etcd static pod on clusters without guard
+	// PDB. It's empty if none was found.
+	GuardPod      string
+	GuardPodReady bool
+}
+
+// String summarizes the health for logging
+func (h *ControlPlaneHealth) String() string {
+	readyGuardPods := 0
+	for _, node := range h.Nodes {
+		if node.GuardPodReady {
+			readyGuardPods++
+		}
+	}
+	return fmt.Sprintf("%d/%d control plane nodes ready, %d/%d guard pods ready, %d disruptions allowed",
+		h.ReadyNodes, h.TotalNodes, readyGuardPods, len(h.Nodes), h.DisruptionsAllowed)
+}
+
+// GetControlPlaneHealth returns a snapshot of the control plane nodes, their guard pods and the guard PDB.
+// Empty options are replaced by the defaults.
+func GetControlPlaneHealth(ctx context.Context, cl client.Reader, options Options) (*ControlPlaneHealth, error) {
+	state, err := getGuardState(ctx, cl, options.withDefaults())
+	if err != nil {
+		return nil, err
+	}
+	nodeList := &corev1.NodeList{}
+	if err := cl.List(ctx, nodeList); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	health := &ControlPlaneHealth{}
+	pods := state.guardPods
+	switch {
+	case state.pdb != nil:
+		health.PDB = state.pdb.Namespace + "/" + state.pdb.Name
+		health.DisruptionsAllowed = state.pdb.Status.DisruptionsAllowed
+	case len(state.staticPods) > 0:
+		pods = state.staticPods
+		health.DisruptionsAllowed = int32(state.staticPodDisruptionsTolerable())
+	}
+
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		if !nodes.IsControlPlane(node) {
+			continue
+		}
+		nodeHealth := NodeHealth{Name: node.Name, Ready: nodes.IsReady(node)}
+		for j := range pods {
+			if pods[j].Spec.NodeName == node.Name {
+				nodeHealth.GuardPod = pods[j].Namespace + "/" + pods[j].Name
+				nodeHealth.GuardPodReady = isPodReady(&pods[j])
+				break
+			}
+		}
+		health.TotalNodes++
+		if nodeHealth.Ready {
+			health.ReadyNodes++
+		}
+		health.Nodes = append(health.Nodes, nodeHealth)
+	}
+	return health, nil
+}
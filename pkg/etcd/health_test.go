@@ -0,0 +1,25 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetControlPlaneHealthNamespace(t *testing.T) {
+	pdb := guardPDB("etcd-guard-pdb", 1)
+	pdb.Namespace = "etcd"
+	guard := guardPod("master-0", true)
+	guard.Namespace = "etcd"
+	cl := newClient(controlPlaneNode("master-0", true), pdb, guard)
+
+	health, err := GetControlPlaneHealth(context.Background(), cl, Options{Namespace: "etcd"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if health.PDB != "etcd/etcd-guard-pdb" || health.DisruptionsAllowed != 1 {
+		t.Errorf("expected guard PDB etcd/etcd-guard-pdb allowing 1 disruption, got %s", health)
+	}
+	if len(health.Nodes) != 1 || health.Nodes[0].GuardPod != "etcd/etcd-guard-master-0" || !health.Nodes[0].GuardPodReady {
+		t.Errorf("expected the ready guard pod of master-0, got %+v", health.Nodes)
+	}
+}
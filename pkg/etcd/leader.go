@@ -0,0 +1,92 @@
+package etcd
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/common/pkg/nodes"
+)
+
+// LeaderPolicy returns true if the node hosting the etcd leader may be disrupted now. otherCandidates are the other
+// nodes which are waiting for disruption and which etcd quorum protection allows to be disrupted.
+type LeaderPolicy func(leaderNode *corev1.Node, otherCandidates []*corev1.Node) bool
+
+// AllowLeader is a LeaderPolicy which never delays disrupting the leader
+func AllowLeader(_ *corev1.Node, _ []*corev1.Node) bool {
+	return true
+}
+
+// PreferNonLeader is a LeaderPolicy which delays disrupting the leader while other control plane nodes can be
+// disrupted instead, so that followers are handled first and the number of leader elections is kept low
+func PreferNonLeader(_ *corev1.Node, otherCandidates []*corev1.Node) bool {
+	for _, candidate := range otherCandidates {
+		if nodes.IsControlPlane(candidate) {
+			return false
+		}
+	}
+	return true
+}
+
+// Leader returns the leader member, or nil if no member is known to be the leader
+func (r *MemberHealthReport) Leader() *MemberHealth {
+	for i := range r.Members {
+		if r.Members[i].IsLeader {
+			return &r.Members[i]
+		}
+	}
+	return nil
+}
+
+// IsEtcdLeaderNode returns true if the node hosts the current etcd leader, based on the members' endpoint status
+func IsEtcdLeaderNode(ctx context.Context, cl client.Reader, options Options, node *corev1.Node) (bool, error) {
+	if !nodes.IsControlPlane(node) {
+		return false, nil
+	}
+	report, err := GetMemberHealth(ctx, cl, options)
+	if err != nil {
+		return false, err
+	}
+	leader := report.Leader()
+	return leader != nil && leader.Name == node.Name, nil
+}
+
+// ShouldDelayDisruption returns true if the node hosts the etcd leader and the policy wants to delay its disruption
+// in favour of the other candidates. Only candidates which can be disrupted are passed to the policy, waiting for
+// refused candidates would delay the leader forever.
+func ShouldDelayDisruption(ctx context.Context, cl client.Reader, options Options, node *corev1.Node, otherCandidates []*corev1.Node, policy LeaderPolicy) (bool, error) {
+	options = options.withDefaults()
+	isLeader, err := IsEtcdLeaderNode(ctx, cl, options, node)
+	if err != nil || !isLeader {
+		return false, err
+	}
+	allowed, err := allowedCandidates(ctx, cl, options, otherCandidates)
+	if err != nil {
+		return false, err
+	}
+	if policy(node, allowed) {
+		return false, nil
+	}
+	decisionLog.Info(options.Logger, "leader/"+node.Name, "delaying disruption of etcd leader node", "node", node.Name, "otherCandidates", len(allowed))
+	return true, nil
+}
+
+// allowedCandidates returns the candidates which can be disrupted, they share the disruption budget
+func allowedCandidates(ctx context.Context, cl client.Reader, options Options, candidates []*corev1.Node) ([]*corev1.Node, error) {
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	state, err := getGuardState(ctx, cl, options)
+	if err != nil {
+		return nil, err
+	}
+	decisions := state.decideAll(options.Logger, candidates)
+	var allowed []*corev1.Node
+	for _, candidate := range candidates {
+		if decisions[candidate.Name].Allowed {
+			allowed = append(allowed, candidate)
+		}
+	}
+	return allowed, nil
+}
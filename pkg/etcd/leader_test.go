@@ -0,0 +1,40 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestPreferNonLeaderWithAllowedCandidates(t *testing.T) {
+	leader, follower := controlPlaneNode("master-0", true), controlPlaneNode("master-1", true)
+	tests := []struct {
+		name        string
+		objs        []client.Object
+		leaderAllow bool
+	}{
+		{
+			name:        "follower can be disrupted",
+			objs:        []client.Object{guardPDB("etcd-guard-pdb", 1), guardPod("master-0", true), guardPod("master-1", true)},
+			leaderAllow: false,
+		},
+		{
+			name:        "follower is refused",
+			objs:        []client.Object{guardPDB("etcd-guard-pdb", 0), guardPod("master-0", true), guardPod("master-1", true)},
+			leaderAllow: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, err := allowedCandidates(context.Background(), newClient(tt.objs...), Options{}.withDefaults(), []*corev1.Node{follower})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if leaderAllowed := PreferNonLeader(leader, allowed); leaderAllowed != tt.leaderAllow {
+				t.Errorf("expected leader disruption allowed %t, got %t", tt.leaderAllow, leaderAllowed)
+			}
+		})
+	}
+}
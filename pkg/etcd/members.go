@@ -0,0 +1,359 @@
+package etcd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/common/pkg/nodes"
+	"github.com/medik8s/common/pkg/tracing"
+)
+
+const (
+	// ClientCertSecretName is the name of the Secret in the etcd namespace holding the etcd client certificate
+	ClientCertSecretName = "etcd-client"
+	// CABundleConfigMapName is the name of the ConfigMap in the etcd namespace holding the etcd CA bundle
+	CABundleConfigMapName = "etcd-ca-bundle"
+	// CABundleKey is the key of the CA bundle in the CABundleConfigMapName ConfigMap
+	CABundleKey = "ca-bundle.crt"
+	// EndpointsConfigMapName is the name of the ConfigMap in the etcd namespace holding the etcd member IPs
+	EndpointsConfigMapName = "etcd-endpoints"
+	// ClientPort is the port etcd members serve clients on
+	ClientPort = "2379"
+
+	// DefaultMemberTimeout is the default timeout for talking to etcd members
+	DefaultMemberTimeout = 5 * time.Second
+	// MaxRaftIndexLag is the number of raft entries a member may lag behind the leader before it's considered unhealthy
+	MaxRaftIndexLag = 1000
+)
+
+const (
+	// ReasonNoMember means the node doesn't host an etcd member
+	ReasonNoMember Reason = "NoMember"
+	// ReasonMemberUnhealthy means the node's etcd member is already unhealthy, so disrupting the node doesn't
+	// reduce the number of healthy etcd members further
+	ReasonMemberUnhealthy Reason = "MemberUnhealthy"
+	// ReasonMembersHealthy means enough healthy etcd members remain when the node is disrupted
+	ReasonMembersHealthy Reason = "MembersHealthy"
+	// ReasonAlarmActive means etcd has an active alarm in RefusingAlarms
+	ReasonAlarmActive Reason = "AlarmActive"
+	// ReasonLearner means the node only hosts a learner member, which doesn't count for quorum
+	ReasonLearner Reason = "Learner"
+)
+
+// MemberRole is the role of the etcd member hosted by a node
+type MemberRole string
+
+const (
+	// MemberRoleNone means the node doesn't host an etcd member, or the members weren't queried
+	MemberRoleNone MemberRole = ""
+	// MemberRoleVoter is a regular member, which counts for quorum
+	MemberRoleVoter MemberRole = "Voter"
+	// MemberRoleLearner is a non-voting member, which doesn't count for quorum
+	MemberRoleLearner MemberRole = "Learner"
+)
+
+// RefusingAlarms are the etcd alarms which refuse the disruption of healthy members, since recovering from them
+// with fewer members is much harder. Other alarms are only logged.
+var RefusingAlarms = []string{"NOSPACE", "CORRUPT"}
+
+// MemberHealth is the health of a single etcd member
+type MemberHealth struct {
+	ID uint64
+	// Name is the member name, which is the node name on OpenShift
+	Name      string
+	Endpoint  string
+	Healthy   bool
+	IsLeader  bool
+	IsLearner bool
+	RaftIndex uint64
+	// Lag is the number of raft entries the member is behind the leader
+	Lag uint64
+	// Error explains why the member is unhealthy, if it is
+	Error string
+}
+
+// Alarm is an active etcd alarm
+type Alarm struct {
+	MemberID uint64
+	// Type is the alarm type, e.g. NOSPACE or CORRUPT
+	Type string
+}
+
+// MemberHealthReport is the health of all etcd members
+type MemberHealthReport struct {
+	Members []MemberHealth
+	Alarms  []Alarm
+}
+
+// Voters returns the number of voting members, learners don't count for quorum
+func (r *MemberHealthReport) Voters() int {
+	voters := 0
+	for _, member := range r.Members {
+		if !member.IsLearner {
+			voters++
+		}
+	}
+	return voters
+}
+
+// Healthy returns the number of healthy voting members
+func (r *MemberHealthReport) Healthy() int {
+	healthy := 0
+	for _, member := range r.Members {
+		if member.Healthy && !member.IsLearner {
+			healthy++
+		}
+	}
+	return healthy
+}
+
+// HasQuorum returns true if a majority of voting members is healthy
+func (r *MemberHealthReport) HasQuorum() bool {
+	quorumSize, _ := ComputeQuorum(r.Voters(), r.Healthy())
+	return quorumSize > 0 && r.Healthy() >= quorumSize
+}
+
+// Member returns the member with the given name, or nil
+func (r *MemberHealthReport) Member(name string) *MemberHealth {
+	for i := range r.Members {
+		if r.Members[i].Name == name {
+			return &r.Members[i]
+		}
+	}
+	return nil
+}
+
+// RoleOf returns the role of the member hosted by the given node
+func (r *MemberHealthReport) RoleOf(nodeName string) MemberRole {
+	member := r.Member(nodeName)
+	switch {
+	case member == nil:
+		return MemberRoleNone
+	case member.IsLearner:
+		return MemberRoleLearner
+	default:
+		return MemberRoleVoter
+	}
+}
+
+// GetMemberHealth connects to the etcd members using the client certificate and CA bundle from options.Namespace,
+// and reports the health, leader and raft lag of every member. Empty options are replaced by the defaults.
+// A member is unhealthy if its status can't be fetched, if it reports errors, or if it lags more than
+// MaxRaftIndexLag entries behind the leader.
+func GetMemberHealth(ctx context.Context, cl client.Reader, options Options) (report *MemberHealthReport, err error) {
+	ctx, span := tracing.Start(ctx, "etcd.GetMemberHealth", attribute.String("namespace", options.Namespace))
+	defer func() {
+		if report != nil {
+			span.SetAttributes(attribute.Int("members", len(report.Members)), attribute.Int("alarms", len(report.Alarms)))
+		}
+		tracing.End(span, err)
+	}()
+
+	options = options.withDefaults()
+	timeout := options.MemberTimeout
+	tlsConfig, err := getClientTLSConfig(ctx, cl, options.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := getEndpoints(ctx, cl, options.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	etcdClient, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		TLS:         tlsConfig,
+		DialTimeout: timeout,
+		Context:     ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create etcd client: %w", err)
+	}
+	defer etcdClient.Close()
+
+	listCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	memberList, err := etcdClient.MemberList(listCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list etcd members: %w", err)
+	}
+
+	alarmCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	alarms, err := etcdClient.AlarmList(alarmCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list etcd alarms: %w", err)
+	}
+
+	report = &MemberHealthReport{}
+	for _, alarm := range alarms.Alarms {
+		report.Alarms = append(report.Alarms, Alarm{MemberID: alarm.MemberID, Type: alarm.Alarm.String()})
+	}
+	var leaderID, leaderIndex uint64
+	for _, member := range memberList.Members {
+		health := MemberHealth{ID: member.ID, Name: member.Name, IsLearner: member.IsLearner}
+		if len(member.ClientURLs) == 0 {
+			// not started yet
+			health.Error = "member has no client URLs"
+			report.Members = append(report.Members, health)
+			continue
+		}
+		health.Endpoint = member.ClientURLs[0]
+
+		statusCtx, cancel := context.WithTimeout(ctx, timeout)
+		status, err := etcdClient.Status(statusCtx, health.Endpoint)
+		cancel()
+		if err != nil {
+			health.Error = err.Error()
+			report.Members = append(report.Members, health)
+			continue
+		}
+		if len(status.Errors) > 0 {
+			health.Error = fmt.Sprintf("member reports errors: %v", status.Errors)
+		} else {
+			health.Healthy = true
+		}
+		health.RaftIndex = status.RaftIndex
+		if status.Leader == member.ID {
+			health.IsLeader = true
+		}
+		if status.Leader != 0 {
+			leaderID = status.Leader
+		}
+		report.Members = append(report.Members, health)
+	}
+
+	for _, member := range report.Members {
+		if member.ID == leaderID {
+			leaderIndex = member.RaftIndex
+		}
+	}
+	for i := range report.Members {
+		member := &report.Members[i]
+		if !member.Healthy || leaderIndex <= member.RaftIndex {
+			continue
+		}
+		member.Lag = leaderIndex - member.RaftIndex
+		if member.Lag > MaxRaftIndexLag {
+			member.Healthy = false
+			member.Error = fmt.Sprintf("member lags %d raft entries behind the leader", member.Lag)
+		}
+	}
+	return report, nil
+}
+
+// IsEtcdMemberDisruptionAllowed decides whether the node can be disrupted based on the health of the etcd members,
+// rather than on the guard PDB. This catches members which are running, but unhealthy or lagging.
+func IsEtcdMemberDisruptionAllowed(ctx context.Context, cl client.Reader, options Options, node *corev1.Node) (DisruptionDecision, error) {
+	options = options.withDefaults()
+	decision := DisruptionDecision{NodeName: node.Name}
+	if !nodes.IsControlPlane(node) {
+		decision.Allowed = true
+		decision.Reason = ReasonNotControlPlane
+		return decision, nil
+	}
+	report, err := GetMemberHealth(ctx, cl, options)
+	if err != nil {
+		return decision, err
+	}
+	decision = decideByMembers(report, node.Name)
+	recordDecision(decision)
+	decisionLog.Info(options.Logger, "member/"+node.Name+"/"+string(decision.Reason), "etcd member disruption decision",
+		"node", node.Name, "allowed", decision.Allowed, "reason", decision.Reason, "alarms", decision.Alarms)
+	return decision, nil
+}
+
+func decideByMembers(report *MemberHealthReport, nodeName string) DisruptionDecision {
+	decision := DisruptionDecision{NodeName: nodeName}
+	var refusingAlarm bool
+	decision.Alarms, refusingAlarm = alarmTypes(report.Alarms)
+	member := report.Member(nodeName)
+	decision.MemberRole = report.RoleOf(nodeName)
+	_, tolerable := ComputeQuorum(report.Voters(), report.Healthy())
+	switch {
+	case member == nil:
+		decision.Allowed = true
+		decision.Reason = ReasonNoMember
+	case member.IsLearner:
+		decision.Allowed = true
+		decision.Reason = ReasonLearner
+	case !member.Healthy:
+		decision.Allowed = true
+		decision.Reason = ReasonMemberUnhealthy
+	case refusingAlarm:
+		decision.Reason = ReasonAlarmActive
+	case tolerable >= 1:
+		decision.Allowed = true
+		decision.Reason = ReasonMembersHealthy
+	default:
+		decision.Reason = ReasonQuorumAtRisk
+	}
+	return decision
+}
+
+// alarmTypes returns the types of the alarms, and whether one of them is in RefusingAlarms
+func alarmTypes(alarms []Alarm) ([]string, bool) {
+	var types []string
+	refusing := false
+	for _, alarm := range alarms {
+		types = append(types, alarm.Type)
+		for _, refusingType := range RefusingAlarms {
+			if alarm.Type == refusingType {
+				refusing = true
+			}
+		}
+	}
+	return types, refusing
+}
+
+func getClientTLSConfig(ctx context.Context, cl client.Reader, namespace string) (*tls.Config, error) {
+	secret := &corev1.Secret{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ClientCertSecretName}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get etcd client secret: %w", err)
+	}
+	cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid etcd client certificate: %w", err)
+	}
+
+	caBundle := &corev1.ConfigMap{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: CABundleConfigMapName}, caBundle); err != nil {
+		return nil, fmt.Errorf("failed to get etcd CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(caBundle.Data[CABundleKey])) {
+		return nil, fmt.Errorf("no certificates found in etcd CA bundle")
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+func getEndpoints(ctx context.Context, cl client.Reader, namespace string) ([]string, error) {
+	cm := &corev1.ConfigMap{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: EndpointsConfigMapName}, cm); err != nil {
+		return nil, fmt.Errorf("failed to get etcd endpoints: %w", err)
+	}
+	var endpoints []string
+	for _, ip := range cm.Data {
+		endpoints = append(endpoints, "https://"+net.JoinHostPort(ip, ClientPort))
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no etcd endpoints found in %s/%s", namespace, EndpointsConfigMapName)
+	}
+	sort.Strings(endpoints)
+	return endpoints, nil
+}
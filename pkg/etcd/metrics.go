@@ -0,0 +1,51 @@
+package etcd
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	checksTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "medik8s_etcd_disruption_checks_total",
+		Help: "Number of etcd disruption checks of nodes",
+	})
+	refusalsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "medik8s_etcd_disruption_refusals_total",
+		Help: "Number of etcd disruption checks which refused the disruption, by reason",
+	}, []string{"reason"})
+	disruptionsAllowed = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "medik8s_etcd_guard_pdb_disruptions_allowed",
+		Help: "DisruptionsAllowed of the etcd guard PDB, or the tolerable disruptions derived from etcd static pods, at the last check",
+	})
+	unhealthyGuardPods = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "medik8s_etcd_unhealthy_guard_pods",
+		Help: "Number of not ready etcd guard pods, or etcd static pods on clusters without guard PDB, at the last check",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(checksTotal, refusalsTotal, disruptionsAllowed, unhealthyGuardPods)
+}
+
+func recordState(state *guardState) {
+	switch {
+	case state.pdb != nil:
+		disruptionsAllowed.Set(float64(state.pdb.Status.DisruptionsAllowed))
+		unhealthyGuardPods.Set(float64(len(state.guardPods) - countReady(state.guardPods)))
+	case len(state.staticPods) > 0:
+		disruptionsAllowed.Set(float64(state.staticPodDisruptionsTolerable()))
+		unhealthyGuardPods.Set(float64(len(state.staticPods) - countReady(state.staticPods)))
+	default:
+		// don't keep reporting the values of a PDB or static pods which are gone
+		disruptionsAllowed.Set(0)
+		unhealthyGuardPods.Set(0)
+	}
+}
+
+func recordDecision(decision DisruptionDecision) {
+	checksTotal.Inc()
+	if !decision.Allowed {
+		refusalsTotal.WithLabelValues(string(decision.Reason)).Inc()
+	}
+}
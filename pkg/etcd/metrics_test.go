@@ -0,0 +1,20 @@
+package etcd
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRecordStateResetsGauges(t *testing.T) {
+	recordState(&guardState{pdb: guardPDB("etcd-guard-pdb", 1), guardPods: []corev1.Pod{*guardPod("master-0", false)}})
+	if allowed, unhealthy := testutil.ToFloat64(disruptionsAllowed), testutil.ToFloat64(unhealthyGuardPods); allowed != 1 || unhealthy != 1 {
+		t.Fatalf("expected 1 disruption allowed and 1 unhealthy guard pod, got %v and %v", allowed, unhealthy)
+	}
+
+	recordState(&guardState{noPDBReason: ReasonNoPDB})
+	if allowed, unhealthy := testutil.ToFloat64(disruptionsAllowed), testutil.ToFloat64(unhealthyGuardPods); allowed != 0 || unhealthy != 0 {
+		t.Errorf("expected the gauges to be reset without PDB, got %v and %v", allowed, unhealthy)
+	}
+}
@@ -0,0 +1,41 @@
+package etcd
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/medik8s/common/pkg/nodes"
+)
+
+// MissingPDBPolicy decides about disruptions when no guard PDB exists, e.g. on single node or compact clusters
+type MissingPDBPolicy string
+
+const (
+	// MissingPDBPolicyRefuse refuses the disruption of control plane nodes
+	MissingPDBPolicyRefuse MissingPDBPolicy = "Refuse"
+	// MissingPDBPolicyAllowWithWarning allows the disruption of control plane nodes, and logs a warning
+	MissingPDBPolicyAllowWithWarning MissingPDBPolicy = "AllowWithWarning"
+	// MissingPDBPolicyFallbackToMemberCount assumes an etcd member on every control plane node, and derives the
+	// quorum from the number of ready control plane nodes
+	MissingPDBPolicyFallbackToMemberCount MissingPDBPolicy = "FallbackToMemberCount"
+)
+
+func (s *guardState) decideByMissingPDBPolicy(node *corev1.Node) DisruptionDecision {
+	decision := DisruptionDecision{NodeName: node.Name, Reason: ReasonNoPDB}
+	switch s.missingPDBPolicy {
+	case MissingPDBPolicyAllowWithWarning:
+		decision.Allowed = true
+	case MissingPDBPolicyFallbackToMemberCount:
+		_, tolerable := ComputeQuorum(s.controlPlaneNodes, s.readyControlPlaneNodes)
+		switch {
+		case !nodes.IsReady(node):
+			decision.Allowed = true
+			decision.Reason = ReasonNodeAlreadyDisrupted
+		case tolerable >= 1:
+			decision.Allowed = true
+			decision.Reason = ReasonMembersHealthy
+		default:
+			decision.Reason = ReasonQuorumAtRisk
+		}
+	}
+	return decision
+}
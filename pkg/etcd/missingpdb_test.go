@@ -0,0 +1,69 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestDecideByMissingPDBPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  MissingPDBPolicy
+		nodes   []client.Object
+		node    string
+		allowed bool
+		reason  Reason
+	}{
+		{
+			name:   "refuse by default",
+			nodes:  []client.Object{controlPlaneNode("master-0", true)},
+			node:   "master-0",
+			reason: ReasonNoPDB,
+		},
+		{
+			name:    "allow with warning",
+			policy:  MissingPDBPolicyAllowWithWarning,
+			nodes:   []client.Object{controlPlaneNode("master-0", true)},
+			node:    "master-0",
+			allowed: true,
+			reason:  ReasonNoPDB,
+		},
+		{
+			name:    "member count allows",
+			policy:  MissingPDBPolicyFallbackToMemberCount,
+			nodes:   []client.Object{controlPlaneNode("master-0", true), controlPlaneNode("master-1", true), controlPlaneNode("master-2", true)},
+			node:    "master-0",
+			allowed: true,
+			reason:  ReasonMembersHealthy,
+		},
+		{
+			name:   "member count refuses",
+			policy: MissingPDBPolicyFallbackToMemberCount,
+			nodes:  []client.Object{controlPlaneNode("master-0", true), controlPlaneNode("master-1", false), controlPlaneNode("master-2", true)},
+			node:   "master-0",
+			reason: ReasonQuorumAtRisk,
+		},
+		{
+			name:    "member count allows not ready node",
+			policy:  MissingPDBPolicyFallbackToMemberCount,
+			nodes:   []client.Object{controlPlaneNode("master-0", true), controlPlaneNode("master-1", false), controlPlaneNode("master-2", true)},
+			node:    "master-1",
+			allowed: true,
+			reason:  ReasonNodeAlreadyDisrupted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := getGuardState(context.Background(), newClient(tt.nodes...), Options{MissingPDBPolicy: tt.policy}.withDefaults())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			decision := state.decide(controlPlaneNode(tt.node, tt.reason != ReasonNodeAlreadyDisrupted))
+			if decision.Allowed != tt.allowed || decision.Reason != tt.reason {
+				t.Errorf("expected allowed %t with reason %s, got %t with reason %s", tt.allowed, tt.reason, decision.Allowed, decision.Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package etcd
+
+// ComputeQuorum returns the quorum size of an etcd cluster with totalMembers voting members, and how many of its
+// healthyMembers can be disrupted additionally without losing quorum.
+// disruptionsTolerable is 0 if quorum is already lost.
+func ComputeQuorum(totalMembers, healthyMembers int) (quorumSize int, disruptionsTolerable int) {
+	if totalMembers <= 0 {
+		return 0, 0
+	}
+	quorumSize = totalMembers/2 + 1
+	if healthyMembers > quorumSize {
+		disruptionsTolerable = healthyMembers - quorumSize
+	}
+	return quorumSize, disruptionsTolerable
+}
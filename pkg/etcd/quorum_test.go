@@ -0,0 +1,26 @@
+package etcd
+
+import "testing"
+
+func TestComputeQuorum(t *testing.T) {
+	tests := []struct {
+		total, healthy      int
+		quorum, disruptions int
+	}{
+		{total: 0, healthy: 0, quorum: 0, disruptions: 0},
+		{total: 1, healthy: 1, quorum: 1, disruptions: 0},
+		{total: 2, healthy: 2, quorum: 2, disruptions: 0},
+		{total: 3, healthy: 3, quorum: 2, disruptions: 1},
+		{total: 3, healthy: 2, quorum: 2, disruptions: 0},
+		{total: 3, healthy: 1, quorum: 2, disruptions: 0},
+		{total: 4, healthy: 4, quorum: 3, disruptions: 1},
+		{total: 5, healthy: 5, quorum: 3, disruptions: 2},
+		{total: 5, healthy: 4, quorum: 3, disruptions: 1},
+	}
+	for _, tt := range tests {
+		quorum, disruptions := ComputeQuorum(tt.total, tt.healthy)
+		if quorum != tt.quorum || disruptions != tt.disruptions {
+			t.Errorf("ComputeQuorum(%d, %d) = (%d, %d), expected (%d, %d)", tt.total, tt.healthy, quorum, disruptions, tt.quorum, tt.disruptions)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package etcd
+
+import (
+	"testing"
+
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestSelectGuardPDB(t *testing.T) {
+	labeled := func(name string, pdbLabels map[string]string) policyv1.PodDisruptionBudget {
+		pdb := guardPDB(name, 1)
+		pdb.Labels = pdbLabels
+		return *pdb
+	}
+	tests := []struct {
+		name     string
+		pdbs     []policyv1.PodDisruptionBudget
+		options  Options
+		expected string
+		reason   Reason
+	}{
+		{
+			name:   "no PDB",
+			reason: ReasonNoPDB,
+		},
+		{
+			name:     "single PDB",
+			pdbs:     []policyv1.PodDisruptionBudget{labeled("custom", nil)},
+			expected: "custom",
+		},
+		{
+			name:     "explicit name",
+			pdbs:     []policyv1.PodDisruptionBudget{labeled("a", nil), labeled("b", nil)},
+			options:  Options{PDBName: "b"},
+			expected: "b",
+		},
+		{
+			name:    "explicit name not found",
+			pdbs:    []policyv1.PodDisruptionBudget{labeled("a", nil)},
+			options: Options{PDBName: "b"},
+			reason:  ReasonNoPDB,
+		},
+		{
+			name:     "selector",
+			pdbs:     []policyv1.PodDisruptionBudget{labeled("a", map[string]string{"guard": "true"}), labeled("b", nil)},
+			options:  Options{PDBSelector: labels.SelectorFromSet(labels.Set{"guard": "true"})},
+			expected: "a",
+		},
+		{
+			name:    "selector without match",
+			pdbs:    []policyv1.PodDisruptionBudget{labeled("a", nil)},
+			options: Options{PDBSelector: labels.SelectorFromSet(labels.Set{"guard": "true"})},
+			reason:  ReasonNoPDB,
+		},
+		{
+			name:     "well-known name",
+			pdbs:     []policyv1.PodDisruptionBudget{labeled("other", nil), labeled("etcd-guard-pdb", nil)},
+			expected: "etcd-guard-pdb",
+		},
+		{
+			name:   "ambiguous",
+			pdbs:   []policyv1.PodDisruptionBudget{labeled("a", nil), labeled("b", nil)},
+			reason: ReasonMultiplePDBs,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdb, reason := selectGuardPDB(tt.pdbs, tt.options)
+			if tt.expected == "" {
+				if pdb != nil {
+					t.Fatalf("expected no PDB, got %s", pdb.Name)
+				}
+				if reason != tt.reason {
+					t.Errorf("expected reason %s, got %s", tt.reason, reason)
+				}
+				return
+			}
+			if pdb == nil {
+				t.Fatalf("expected PDB %s, got none with reason %s", tt.expected, reason)
+			}
+			if pdb.Name != tt.expected {
+				t.Errorf("expected PDB %s, got %s", tt.expected, pdb.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package etcd
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/common/pkg/tracing"
+)
+
+// SimulationResult is the outcome of SimulateDisruption
+type SimulationResult struct {
+	Allowed bool
+	Reason  Reason
+	// NewlyDisrupted are the given nodes which host a healthy etcd member, and so would reduce the number of
+	// healthy members. Nodes whose guard pod is already not ready, or which host no member, aren't included.
+	NewlyDisrupted []string
+	// DisruptionsTolerable is the number of healthy members which can be disrupted without losing quorum
+	DisruptionsTolerable int
+	// Alarms are the active etcd alarms, only set when options.CheckAlarms is set
+	Alarms []string
+}
+
+// SimulateDisruption reports whether disrupting all given nodes at the same time would break etcd quorum.
+// Nodes whose guard pod is already not ready don't count, since they are already disrupted, and duplicate names are
+// counted once. Without guard PDB and etcd static pods, options.MissingPDBPolicy decides. Like the checker, learner
+// members don't count with options.DetectLearners, and options.CheckAlarms refuses the disruption of healthy members
+// while an alarm in RefusingAlarms is active. Empty options are replaced by the defaults.
+func SimulateDisruption(ctx context.Context, cl client.Reader, options Options, nodesToDisrupt []string) (_ *SimulationResult, err error) {
+	ctx, span := tracing.Start(ctx, "etcd.SimulateDisruption", attribute.StringSlice("nodes", nodesToDisrupt))
+	defer func() { tracing.End(span, err) }()
+
+	options = options.withDefaults()
+	state, err := getGuardState(ctx, cl, options)
+	if err != nil {
+		return nil, err
+	}
+	if err := state.addMembers(ctx, cl, options); err != nil {
+		return nil, err
+	}
+	result := state.simulate(nodesToDisrupt)
+	sortedNodes := append([]string(nil), nodesToDisrupt...)
+	sort.Strings(sortedNodes)
+	key := "simulation/" + strings.Join(sortedNodes, ",") + "/" + string(result.Reason)
+	if result.Allowed && result.Reason == ReasonNoPDB {
+		decisionLog.Info(options.Logger, "warning/"+key, "Warning: allowing disruption without etcd guard PDB", "nodes", nodesToDisrupt)
+	}
+	decisionLog.Info(options.Logger, key, "etcd disruption simulation", "nodes", nodesToDisrupt, "allowed", result.Allowed, "reason", result.Reason,
+		"newlyDisrupted", result.NewlyDisrupted, "disruptionsTolerable", result.DisruptionsTolerable)
+	return result, nil
+}
+
+func (s *guardState) simulate(nodeNames []string) *SimulationResult {
+	result := &SimulationResult{}
+	s.simulateQuorum(result, nodeNames)
+	var refusing bool
+	result.Alarms, refusing = alarmTypes(s.alarms)
+	if refusing && result.Allowed && (len(result.NewlyDisrupted) > 0 || result.Reason == ReasonNoPDB) {
+		result.Allowed = false
+		result.Reason = ReasonAlarmActive
+	}
+	return result
+}
+
+func (s *guardState) simulateQuorum(result *SimulationResult, nodeNames []string) {
+	// isHealthyMember returns true if the node hosts a healthy member
+	var isHealthyMember func(nodeName string) bool
+	switch {
+	case s.pdb != nil:
+		isHealthyMember = readyPodOnNode(s.guardPods)
+		result.DisruptionsTolerable = int(s.pdb.Status.DisruptionsAllowed)
+	case len(s.staticPods) > 0:
+		isHealthyMember = readyPodOnNode(s.staticPods)
+		result.DisruptionsTolerable = s.staticPodDisruptionsTolerable()
+	case s.noPDBReason == ReasonNoPDB && s.missingPDBPolicy == MissingPDBPolicyAllowWithWarning:
+		result.Allowed = true
+		result.Reason = ReasonNoPDB
+		return
+	case s.noPDBReason == ReasonNoPDB && s.missingPDBPolicy == MissingPDBPolicyFallbackToMemberCount:
+		isHealthyMember = func(nodeName string) bool { return s.readyControlPlane[nodeName] }
+		_, result.DisruptionsTolerable = ComputeQuorum(s.controlPlaneNodes, s.readyControlPlaneNodes)
+	default:
+		result.Reason = s.noPDBReason
+		return
+	}
+
+	seen := make(map[string]bool, len(nodeNames))
+	for _, nodeName := range nodeNames {
+		if seen[nodeName] {
+			continue
+		}
+		seen[nodeName] = true
+		// learners don't count for quorum
+		if s.members != nil && s.members.RoleOf(nodeName) == MemberRoleLearner {
+			continue
+		}
+		if isHealthyMember(nodeName) {
+			result.NewlyDisrupted = append(result.NewlyDisrupted, nodeName)
+		}
+	}
+	if len(result.NewlyDisrupted) <= result.DisruptionsTolerable {
+		result.Allowed = true
+		result.Reason = ReasonDisruptionsAllowed
+	} else {
+		result.Reason = ReasonQuorumAtRisk
+	}
+}
+
+func readyPodOnNode(pods []corev1.Pod) func(nodeName string) bool {
+	return func(nodeName string) bool {
+		for i := range pods {
+			if pods[i].Spec.NodeName == nodeName && isPodReady(&pods[i]) {
+				return true
+			}
+		}
+		return false
+	}
+}
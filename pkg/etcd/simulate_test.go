@@ -0,0 +1,173 @@
+package etcd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestSimulateDisruption(t *testing.T) {
+	guard := []client.Object{
+		guardPDB("etcd-guard-pdb", 1),
+		guardPod("master-0", true),
+		guardPod("master-1", true),
+		guardPod("master-2", false),
+	}
+	tests := []struct {
+		name           string
+		objs           []client.Object
+		nodes          []string
+		allowed        bool
+		reason         Reason
+		policy         MissingPDBPolicy
+		newlyDisrupted []string
+	}{
+		{
+			name:           "single node",
+			objs:           guard,
+			nodes:          []string{"master-0"},
+			allowed:        true,
+			reason:         ReasonDisruptionsAllowed,
+			newlyDisrupted: []string{"master-0"},
+		},
+		{
+			name:           "too many nodes",
+			objs:           guard,
+			nodes:          []string{"master-0", "master-1"},
+			reason:         ReasonQuorumAtRisk,
+			newlyDisrupted: []string{"master-0", "master-1"},
+		},
+		{
+			name:           "already disrupted node",
+			objs:           guard,
+			nodes:          []string{"master-0", "master-2"},
+			allowed:        true,
+			reason:         ReasonDisruptionsAllowed,
+			newlyDisrupted: []string{"master-0"},
+		},
+		{
+			name:           "worker node",
+			objs:           guard,
+			nodes:          []string{"worker-0"},
+			allowed:        true,
+			reason:         ReasonDisruptionsAllowed,
+			newlyDisrupted: nil,
+		},
+		{
+			name:           "duplicate nodes",
+			objs:           guard,
+			nodes:          []string{"master-0", "master-0"},
+			allowed:        true,
+			reason:         ReasonDisruptionsAllowed,
+			newlyDisrupted: []string{"master-0"},
+		},
+		{
+			name:   "no PDB",
+			nodes:  []string{"master-0"},
+			reason: ReasonNoPDB,
+		},
+		{
+			name:    "no PDB allowed with warning",
+			nodes:   []string{"master-0"},
+			policy:  MissingPDBPolicyAllowWithWarning,
+			allowed: true,
+			reason:  ReasonNoPDB,
+		},
+		{
+			name:           "no PDB fallback to member count",
+			objs:           []client.Object{controlPlaneNode("master-0", true), controlPlaneNode("master-1", true), controlPlaneNode("master-2", false)},
+			nodes:          []string{"master-0", "master-2"},
+			policy:         MissingPDBPolicyFallbackToMemberCount,
+			reason:         ReasonQuorumAtRisk,
+			newlyDisrupted: []string{"master-0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := SimulateDisruption(context.Background(), newClient(tt.objs...), Options{MissingPDBPolicy: tt.policy}, tt.nodes)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Allowed != tt.allowed || result.Reason != tt.reason {
+				t.Errorf("expected allowed %t with reason %s, got %t with reason %s", tt.allowed, tt.reason, result.Allowed, result.Reason)
+			}
+			if !reflect.DeepEqual(result.NewlyDisrupted, tt.newlyDisrupted) {
+				t.Errorf("expected newly disrupted %v, got %v", tt.newlyDisrupted, result.NewlyDisrupted)
+			}
+		})
+	}
+}
+
+func TestSimulateWithMembers(t *testing.T) {
+	members := &MemberHealthReport{Members: []MemberHealth{
+		{ID: 1, Name: "master-0", Healthy: true, IsLearner: true},
+		{ID: 2, Name: "master-1", Healthy: true},
+		{ID: 3, Name: "master-2", Healthy: true},
+	}}
+	tests := []struct {
+		name           string
+		members        *MemberHealthReport
+		alarms         []Alarm
+		policy         MissingPDBPolicy
+		nodes          []string
+		allowed        bool
+		reason         Reason
+		newlyDisrupted []string
+	}{
+		{
+			name:           "learner doesn't count",
+			members:        members,
+			nodes:          []string{"master-0", "master-1"},
+			allowed:        true,
+			reason:         ReasonDisruptionsAllowed,
+			newlyDisrupted: []string{"master-1"},
+		},
+		{
+			name:           "refusing alarm",
+			alarms:         []Alarm{{MemberID: 2, Type: "NOSPACE"}},
+			nodes:          []string{"master-1"},
+			reason:         ReasonAlarmActive,
+			newlyDisrupted: []string{"master-1"},
+		},
+		{
+			name:    "refusing alarm and only a learner",
+			members: members,
+			alarms:  []Alarm{{MemberID: 2, Type: "CORRUPT"}},
+			nodes:   []string{"master-0"},
+			allowed: true,
+			reason:  ReasonDisruptionsAllowed,
+		},
+		{
+			name:   "refusing alarm without PDB",
+			alarms: []Alarm{{MemberID: 2, Type: "NOSPACE"}},
+			policy: MissingPDBPolicyAllowWithWarning,
+			nodes:  []string{"master-1"},
+			reason: ReasonAlarmActive,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objs []client.Object
+			if tt.policy == "" {
+				objs = []client.Object{guardPDB("etcd-guard-pdb", 1), guardPod("master-0", true), guardPod("master-1", true), guardPod("master-2", true)}
+			}
+			state, err := getGuardState(context.Background(), newClient(objs...), Options{MissingPDBPolicy: tt.policy}.withDefaults())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			state.members, state.alarms = tt.members, tt.alarms
+			result := state.simulate(tt.nodes)
+			if result.Allowed != tt.allowed || result.Reason != tt.reason {
+				t.Errorf("expected allowed %t with reason %s, got %t with reason %s", tt.allowed, tt.reason, result.Allowed, result.Reason)
+			}
+			if !reflect.DeepEqual(result.NewlyDisrupted, tt.newlyDisrupted) {
+				t.Errorf("expected newly disrupted %v, got %v", tt.newlyDisrupted, result.NewlyDisrupted)
+			}
+			if len(result.Alarms) != len(tt.alarms) {
+				t.Errorf("expected alarms %v, got %v", tt.alarms, result.Alarms)
+			}
+		})
+	}
+}
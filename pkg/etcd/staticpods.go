@@ -0,0 +1,100 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/common/pkg/nodes"
+)
+
+const (
+	// StaticPodNamespace is the namespace of the etcd static pods on kubeadm based clusters
+	StaticPodNamespace = "kube-system"
+)
+
+// StaticPodLabels are the labels of the etcd static pods on kubeadm based clusters
+var StaticPodLabels = labels.Set{"component": "etcd"}
+
+// addStaticPods adds the etcd static pods and the number of control plane nodes to the state.
+// On clusters without etcd static pods, e.g. with external etcd, the state is left unchanged.
+func (s *guardState) addStaticPods(ctx context.Context, cl client.Reader, options Options) error {
+	pods := &corev1.PodList{}
+	if err := cl.List(ctx, pods, client.InNamespace(options.StaticPodNamespace), client.MatchingLabelsSelector{Selector: options.StaticPodSelector}); err != nil {
+		return fmt.Errorf("failed to list etcd static pods in %s: %w", options.StaticPodNamespace, err)
+	}
+	if len(pods.Items) == 0 {
+		return nil
+	}
+	if err := s.addControlPlaneNodes(ctx, cl); err != nil {
+		return err
+	}
+	s.staticPods = pods.Items
+	return nil
+}
+
+// addControlPlaneNodes adds the number of all and the names of ready control plane nodes to the state
+func (s *guardState) addControlPlaneNodes(ctx context.Context, cl client.Reader) error {
+	nodeList := &corev1.NodeList{}
+	if err := cl.List(ctx, nodeList); err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	s.readyControlPlane = make(map[string]bool)
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		if !nodes.IsControlPlane(node) {
+			continue
+		}
+		s.controlPlaneNodes++
+		if nodes.IsReady(node) {
+			s.readyControlPlaneNodes++
+			s.readyControlPlane[node.Name] = true
+		}
+	}
+	return nil
+}
+
+// decideByStaticPods decides based on the readiness of the etcd static pods
+func (s *guardState) decideByStaticPods(node *corev1.Node) DisruptionDecision {
+	decision := DisruptionDecision{NodeName: node.Name}
+	var nodePod *corev1.Pod
+	for i := range s.staticPods {
+		if s.staticPods[i].Spec.NodeName == node.Name {
+			nodePod = &s.staticPods[i]
+		}
+	}
+
+	if nodePod == nil {
+		decision.Allowed = true
+		decision.Reason = ReasonNoMember
+		return decision
+	}
+	decision.GuardPod = nodePod.Namespace + "/" + nodePod.Name
+	if !isPodReady(nodePod) {
+		decision.Allowed = true
+		decision.Reason = ReasonMemberUnhealthy
+		return decision
+	}
+
+	if s.staticPodDisruptionsTolerable() >= 1 {
+		decision.Allowed = true
+		decision.Reason = ReasonMembersHealthy
+		return decision
+	}
+	decision.Reason = ReasonQuorumAtRisk
+	return decision
+}
+
+// staticPodDisruptionsTolerable returns how many ready etcd static pods can be disrupted without losing quorum.
+// Every control plane node is expected to run an etcd member.
+func (s *guardState) staticPodDisruptionsTolerable() int {
+	members := s.controlPlaneNodes
+	if len(s.staticPods) > members {
+		members = len(s.staticPods)
+	}
+	_, tolerable := ComputeQuorum(members, countReady(s.staticPods))
+	return tolerable
+}
@@ -0,0 +1,70 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func staticPod(nodeName string, ready bool) *corev1.Pod {
+	return pod(StaticPodNamespace, "etcd-"+nodeName, nodeName, StaticPodLabels, ready)
+}
+
+func TestDecideByStaticPods(t *testing.T) {
+	controlPlane := []client.Object{
+		controlPlaneNode("master-0", true),
+		controlPlaneNode("master-1", true),
+		controlPlaneNode("master-2", true),
+	}
+	tests := []struct {
+		name       string
+		staticPods []client.Object
+		allowed    bool
+		reason     Reason
+	}{
+		{
+			name:       "all members ready",
+			staticPods: []client.Object{staticPod("master-0", true), staticPod("master-1", true), staticPod("master-2", true)},
+			allowed:    true,
+			reason:     ReasonMembersHealthy,
+		},
+		{
+			name:       "other member not ready",
+			staticPods: []client.Object{staticPod("master-0", true), staticPod("master-1", false), staticPod("master-2", true)},
+			reason:     ReasonQuorumAtRisk,
+		},
+		{
+			name:       "other member missing",
+			staticPods: []client.Object{staticPod("master-0", true), staticPod("master-2", true)},
+			reason:     ReasonQuorumAtRisk,
+		},
+		{
+			name:       "member not ready",
+			staticPods: []client.Object{staticPod("master-0", false), staticPod("master-1", true), staticPod("master-2", true)},
+			allowed:    true,
+			reason:     ReasonMemberUnhealthy,
+		},
+		{
+			name:       "no member on node",
+			staticPods: []client.Object{staticPod("master-1", true), staticPod("master-2", true)},
+			allowed:    true,
+			reason:     ReasonNoMember,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := newClient(append(append([]client.Object{}, controlPlane...), tt.staticPods...)...)
+			node := controlPlaneNode("master-0", true)
+			decisions, err := IsEtcdDisruptionAllowedForNodes(context.Background(), cl, []*corev1.Node{node}, Options{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			decision := decisions[node.Name]
+			if decision.Allowed != tt.allowed || decision.Reason != tt.reason {
+				t.Errorf("expected allowed %t with reason %s, got %t with reason %s", tt.allowed, tt.reason, decision.Allowed, decision.Reason)
+			}
+		})
+	}
+}
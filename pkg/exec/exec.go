@@ -0,0 +1,112 @@
+// Package exec runs commands in existing pods using the remotecommand API.
+package exec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"github.com/medik8s/common/pkg/tracing"
+)
+
+const (
+	// DefaultTimeout is used when no timeout is configured
+	DefaultTimeout = time.Minute
+)
+
+// Executor runs commands in pods
+type Executor struct {
+	config    *rest.Config
+	clientSet kubernetes.Interface
+	timeout   time.Duration
+}
+
+// Result contains the captured output of a command
+type Result struct {
+	Stdout string
+	Stderr string
+}
+
+// NewExecutor creates an Executor. A non positive timeout is replaced by DefaultTimeout.
+func NewExecutor(config *rest.Config, timeout time.Duration) (*Executor, error) {
+	clientSet, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Executor{
+		config:    config,
+		clientSet: clientSet,
+		timeout:   timeout,
+	}, nil
+}
+
+// Run executes command in the given container of the pod, and returns its output. An empty container name selects
+// the pod's only container. The command is aborted when ctx is cancelled or the executor's timeout is exceeded.
+// When the command fails, the returned Result still contains the output captured so far.
+func (e *Executor) Run(ctx context.Context, pod *corev1.Pod, container string, command ...string) (*Result, error) {
+	return e.RunWithStdin(ctx, pod, container, nil, command...)
+}
+
+// RunWithStdin is like Run, but additionally streams stdin to the command.
+func (e *Executor) RunWithStdin(ctx context.Context, pod *corev1.Pod, container string, stdin io.Reader, command ...string) (result *Result, err error) {
+	ctx, span := tracing.Start(ctx, "exec.Run",
+		attribute.String("pod", pod.Namespace+"/"+pod.Name),
+		attribute.String("container", container))
+	defer func() { tracing.End(span, err) }()
+
+	if len(command) == 0 {
+		return nil, fmt.Errorf("no command given")
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return nil, fmt.Errorf("pod %s/%s isn't running, phase is %s", pod.Namespace, pod.Name, pod.Status.Phase)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	req := e.clientSet.CoreV1().RESTClient().
+		Post().
+		Namespace(pod.Namespace).
+		Resource("pods").
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(e.config, "POST", req.URL())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create executor: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	result = &Result{
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to execute %q in pod %s/%s: %w", command, pod.Namespace, pod.Name, err)
+	}
+	return result, nil
+}
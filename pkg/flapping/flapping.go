@@ -0,0 +1,188 @@
+// Package flapping detects nodes whose Ready condition changes too often,
+// so that remediation of unstable but recovering nodes can be held off.
+package flapping
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/common/pkg/nodes"
+)
+
+const (
+	// TransitionsAnnotation optionally persists the recent Ready transitions of a node, as a JSON list of timestamps
+	TransitionsAnnotation = "remediation.medik8s.io/ready-transitions"
+
+	// DefaultWindow is the default period in which transitions are counted
+	DefaultWindow = 30 * time.Minute
+	// DefaultThreshold is the default number of transitions within the window which make a node flapping
+	DefaultThreshold = 4
+)
+
+// Detector tracks Ready transitions of nodes in memory. It is safe for concurrent use.
+type Detector struct {
+	window    time.Duration
+	threshold int
+
+	lock        sync.Mutex
+	transitions map[string][]time.Time
+	lastReady   map[string]bool
+}
+
+// NewDetector creates a Detector which reports nodes with at least threshold transitions within window as flapping.
+// Non positive values are replaced by the defaults.
+func NewDetector(window time.Duration, threshold int) *Detector {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	return &Detector{
+		window:      window,
+		threshold:   threshold,
+		transitions: make(map[string][]time.Time),
+		lastReady:   make(map[string]bool),
+	}
+}
+
+// Observe records a transition if the node's readiness changed since the last observation.
+// The first observation of a node restores persisted transitions from the node's annotation, if present.
+func (d *Detector) Observe(node *corev1.Node) {
+	ready := nodes.IsReady(node)
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	lastReady, known := d.lastReady[node.Name]
+	d.lastReady[node.Name] = ready
+	if !known {
+		if persisted, err := parseAnnotation(node); err == nil {
+			d.transitions[node.Name] = persisted
+		}
+		d.prune(node.Name)
+		return
+	}
+	if lastReady == ready {
+		return
+	}
+	transitionTime := time.Now()
+	if condition := readyCondition(node); condition != nil && !condition.LastTransitionTime.IsZero() {
+		transitionTime = condition.LastTransitionTime.Time
+	}
+	d.insert(node.Name, transitionTime)
+	d.prune(node.Name)
+}
+
+// IsFlapping returns true if the node had at least the threshold of transitions within the window.
+func (d *Detector) IsFlapping(nodeName string) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.prune(nodeName)
+	return len(d.transitions[nodeName]) >= d.threshold
+}
+
+// Transitions returns the recorded transitions of the node within the window, oldest first.
+func (d *Detector) Transitions(nodeName string) []time.Time {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.prune(nodeName)
+	return append([]time.Time(nil), d.transitions[nodeName]...)
+}
+
+// FlappingNodes returns the names of all flapping nodes, sorted.
+func (d *Detector) FlappingNodes() []string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	var flapping []string
+	for nodeName := range d.transitions {
+		d.prune(nodeName)
+		if len(d.transitions[nodeName]) >= d.threshold {
+			flapping = append(flapping, nodeName)
+		}
+	}
+	sort.Strings(flapping)
+	return flapping
+}
+
+// Forget removes the node, e.g. after it was deleted.
+func (d *Detector) Forget(nodeName string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	delete(d.transitions, nodeName)
+	delete(d.lastReady, nodeName)
+}
+
+// Persist stores the node's recorded transitions in its annotation, so that they survive operator restarts.
+func (d *Detector) Persist(ctx context.Context, cl client.Client, node *corev1.Node) error {
+	value, err := json.Marshal(d.Transitions(node.Name))
+	if err != nil {
+		return err
+	}
+	if node.Annotations[TransitionsAnnotation] == string(value) {
+		return nil
+	}
+	patch := client.MergeFrom(node.DeepCopy())
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[TransitionsAnnotation] = string(value)
+	if err := cl.Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to persist ready transitions of node %s: %w", node.Name, err)
+	}
+	return nil
+}
+
+// insert adds the transition in order, the transition time of the Ready condition can be older than already recorded
+// transitions. Callers must hold the lock.
+func (d *Detector) insert(nodeName string, transitionTime time.Time) {
+	transitions := d.transitions[nodeName]
+	i := sort.Search(len(transitions), func(i int) bool { return transitions[i].After(transitionTime) })
+	transitions = append(transitions, time.Time{})
+	copy(transitions[i+1:], transitions[i:])
+	transitions[i] = transitionTime
+	d.transitions[nodeName] = transitions
+}
+
+// prune drops transitions outside of the window, transitions are sorted oldest first. Callers must hold the lock.
+func (d *Detector) prune(nodeName string) {
+	transitions := d.transitions[nodeName]
+	cutoff := time.Now().Add(-d.window)
+	i := 0
+	for i < len(transitions) && transitions[i].Before(cutoff) {
+		i++
+	}
+	if i == len(transitions) {
+		delete(d.transitions, nodeName)
+		return
+	}
+	d.transitions[nodeName] = transitions[i:]
+}
+
+func parseAnnotation(node *corev1.Node) ([]time.Time, error) {
+	value, exists := node.Annotations[TransitionsAnnotation]
+	if !exists {
+		return nil, fmt.Errorf("annotation not found")
+	}
+	var transitions []time.Time
+	if err := json.Unmarshal([]byte(value), &transitions); err != nil {
+		return nil, err
+	}
+	sort.Slice(transitions, func(i, j int) bool { return transitions[i].Before(transitions[j]) })
+	return transitions, nil
+}
+
+func readyCondition(node *corev1.Node) *corev1.NodeCondition {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == corev1.NodeReady {
+			return &node.Status.Conditions[i]
+		}
+	}
+	return nil
+}
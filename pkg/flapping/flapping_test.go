@@ -0,0 +1,71 @@
+package flapping
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func node(ready bool, transition time.Time) *corev1.Node {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node"},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{
+			Type:               corev1.NodeReady,
+			Status:             status,
+			LastTransitionTime: metav1.NewTime(transition),
+		}}},
+	}
+}
+
+func TestIsFlapping(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name        string
+		transitions []time.Duration
+		flapping    bool
+		counted     int
+	}{
+		{name: "stable", transitions: nil, flapping: false, counted: 0},
+		{name: "below threshold", transitions: []time.Duration{-3 * time.Minute, -2 * time.Minute, -time.Minute}, flapping: false, counted: 3},
+		{name: "at threshold", transitions: []time.Duration{-4 * time.Minute, -3 * time.Minute, -2 * time.Minute, -time.Minute}, flapping: true, counted: 4},
+		{name: "old transitions outside window", transitions: []time.Duration{-time.Hour, -50 * time.Minute, -2 * time.Minute, -time.Minute}, flapping: false, counted: 2},
+		{name: "out of order transitions", transitions: []time.Duration{-time.Minute, -time.Hour, -2 * time.Minute}, flapping: false, counted: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDetector(30*time.Minute, 4)
+			ready := true
+			d.Observe(node(ready, now.Add(-2*time.Hour)))
+			for _, offset := range tt.transitions {
+				ready = !ready
+				d.Observe(node(ready, now.Add(offset)))
+			}
+			if flapping := d.IsFlapping("node"); flapping != tt.flapping {
+				t.Errorf("expected flapping %t, got %t", tt.flapping, flapping)
+			}
+			if counted := len(d.Transitions("node")); counted != tt.counted {
+				t.Errorf("expected %d transitions, got %d", tt.counted, counted)
+			}
+		})
+	}
+}
+
+func TestForget(t *testing.T) {
+	now := time.Now()
+	d := NewDetector(time.Hour, 1)
+	d.Observe(node(true, now.Add(-time.Hour)))
+	d.Observe(node(false, now))
+	if nodes := d.FlappingNodes(); len(nodes) != 1 || nodes[0] != "node" {
+		t.Fatalf("expected node to be flapping, got %v", nodes)
+	}
+	d.Forget("node")
+	if d.IsFlapping("node") {
+		t.Errorf("expected forgotten node not to be flapping")
+	}
+}
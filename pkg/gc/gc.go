@@ -0,0 +1,210 @@
+// Package gc garbage collects remediation CRs which were left behind after their node or their NHC CR was removed.
+package gc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/medik8s/common/pkg/conditions"
+	"github.com/medik8s/common/pkg/handlers"
+	"github.com/medik8s/common/pkg/remediation"
+)
+
+const (
+	// NodeHealthCheckKind is the kind of the NHC CR which owns remediation CRs
+	NodeHealthCheckKind = "NodeHealthCheck"
+
+	// DefaultGracePeriod is the default time a CR needs to be orphaned before it is deleted
+	DefaultGracePeriod = 10 * time.Minute
+	// DefaultInterval is the default interval between collections
+	DefaultInterval = 5 * time.Minute
+
+	// EventReasonOrphanDeleted is the reason of the event emitted on deleted CRs
+	EventReasonOrphanDeleted = "OrphanedRemediationDeleted"
+)
+
+var log = ctrl.Log.WithName("gc")
+
+// Collector deletes orphaned remediation CRs of the configured kinds. A CR is orphaned if the node it targets doesn't
+// exist anymore, or if its NodeHealthCheck owner is gone. CRs are deleted only after they were seen orphaned for the
+// grace period, the orphan tracking is kept in memory and starts over after restarts.
+// CRs which are still processing, or which expect their node to be deleted permanently, are never orphaned.
+type Collector struct {
+	client      client.Client
+	recorder    record.EventRecorder
+	kinds       []schema.GroupVersionKind
+	gracePeriod time.Duration
+	interval    time.Duration
+
+	lock          sync.Mutex
+	orphanedSince map[types.UID]orphan
+}
+
+// orphan tracks since when a CR is orphaned
+type orphan struct {
+	since time.Time
+	kind  schema.GroupVersionKind
+}
+
+var _ manager.Runnable = &Collector{}
+var _ manager.LeaderElectionRunnable = &Collector{}
+
+// NewCollector creates a Collector. recorder is optional. Non positive durations are replaced by the defaults.
+func NewCollector(cl client.Client, recorder record.EventRecorder, kinds []schema.GroupVersionKind, gracePeriod, interval time.Duration) *Collector {
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultGracePeriod
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Collector{
+		client:        cl,
+		recorder:      recorder,
+		kinds:         kinds,
+		gracePeriod:   gracePeriod,
+		interval:      interval,
+		orphanedSince: make(map[types.UID]orphan),
+	}
+}
+
+// Start collects periodically until the context is cancelled. It implements manager.Runnable.
+func (c *Collector) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if _, err := c.Collect(ctx); err != nil {
+			log.Error(err, "failed to collect orphaned remediation CRs")
+		}
+	}, c.interval)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, only the leader collects.
+func (c *Collector) NeedLeaderElection() bool {
+	return true
+}
+
+// Collect runs a single collection and returns the number of deleted CRs.
+func (c *Collector) Collect(ctx context.Context) (int, error) {
+	// seen are the CRs which are still orphaned, or whose check failed, and failedKinds are the kinds which couldn't
+	// be listed. The orphan tracking of all of them is kept, so that transient errors don't restart the grace period.
+	seen := make(map[types.UID]bool)
+	failedKinds := make(map[schema.GroupVersionKind]bool)
+	deleted := 0
+	var errs []error
+	for _, kind := range c.kinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(kind.GroupVersion().WithKind(kind.Kind + "List"))
+		if err := c.client.List(ctx, list); err != nil {
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("failed to list %s: %w", kind.Kind, err))
+			failedKinds[kind] = true
+			continue
+		}
+		for i := range list.Items {
+			cr := &list.Items[i]
+			if cr.GetDeletionTimestamp() != nil {
+				continue
+			}
+			orphaned, reason, err := c.isOrphaned(ctx, cr)
+			if err != nil {
+				seen[cr.GetUID()] = true
+				errs = append(errs, err)
+				continue
+			}
+			if !orphaned {
+				continue
+			}
+			seen[cr.GetUID()] = true
+			if !c.gracePeriodExpired(cr.GetUID(), kind) {
+				continue
+			}
+			if err := c.client.Delete(ctx, cr); err != nil && !apierrors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("failed to delete orphaned %s %s/%s: %w", cr.GetKind(), cr.GetNamespace(), cr.GetName(), err))
+				continue
+			}
+			deleted++
+			log.Info("deleted orphaned remediation CR", "kind", cr.GetKind(), "namespace", cr.GetNamespace(), "name", cr.GetName(), "reason", reason)
+			if c.recorder != nil {
+				c.recorder.Event(cr, corev1.EventTypeNormal, EventReasonOrphanDeleted, "Deleted orphaned remediation CR: "+reason)
+			}
+		}
+	}
+	c.forgetExcept(seen, failedKinds)
+	return deleted, utilerrors.NewAggregate(errs)
+}
+
+func (c *Collector) isOrphaned(ctx context.Context, cr *unstructured.Unstructured) (bool, string, error) {
+	crConditions := remediation.GetConditions(cr)
+	if meta.IsStatusConditionTrue(crConditions, conditions.ProcessingType) ||
+		meta.IsStatusConditionTrue(crConditions, conditions.PermanentNodeDeletionExpectedType) {
+		return false, "", nil
+	}
+
+	nodeName := handlers.TargetNodeName(cr)
+	if nodeName == "" {
+		log.Info("skipping remediation CR with empty target node name", "kind", cr.GetKind(), "namespace", cr.GetNamespace(), "name", cr.GetName())
+		return false, "", nil
+	}
+	if err := c.client.Get(ctx, client.ObjectKey{Name: nodeName}, &corev1.Node{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, fmt.Sprintf("node %s doesn't exist", nodeName), nil
+		}
+		return false, "", fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+
+	for _, ref := range cr.GetOwnerReferences() {
+		if ref.Kind != NodeHealthCheckKind {
+			continue
+		}
+		owner := &unstructured.Unstructured{}
+		owner.SetAPIVersion(ref.APIVersion)
+		owner.SetKind(ref.Kind)
+		if err := c.client.Get(ctx, client.ObjectKey{Name: ref.Name}, owner); err != nil {
+			if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				return true, fmt.Sprintf("owner %s %s doesn't exist", ref.Kind, ref.Name), nil
+			}
+			return false, "", fmt.Errorf("failed to get owner %s %s: %w", ref.Kind, ref.Name, err)
+		}
+		if owner.GetUID() != ref.UID {
+			return true, fmt.Sprintf("owner %s %s was recreated", ref.Kind, ref.Name), nil
+		}
+	}
+	return false, "", nil
+}
+
+func (c *Collector) gracePeriodExpired(uid types.UID, kind schema.GroupVersionKind) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	o, exists := c.orphanedSince[uid]
+	if !exists {
+		c.orphanedSince[uid] = orphan{since: time.Now(), kind: kind}
+		return false
+	}
+	return time.Since(o.since) >= c.gracePeriod
+}
+
+func (c *Collector) forgetExcept(seen map[types.UID]bool, failedKinds map[schema.GroupVersionKind]bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for uid, o := range c.orphanedSince {
+		if !seen[uid] && !failedKinds[o.kind] {
+			delete(c.orphanedSince, uid)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package gc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/medik8s/common/pkg/annotations"
+	"github.com/medik8s/common/pkg/conditions"
+)
+
+func remediationCR(name string, crAnnotations map[string]string, conditionTypes ...string) *unstructured.Unstructured {
+	cr := &unstructured.Unstructured{}
+	cr.SetAPIVersion("remediation.medik8s.io/v1alpha1")
+	cr.SetKind("TestRemediation")
+	cr.SetNamespace("ns")
+	cr.SetName(name)
+	cr.SetAnnotations(crAnnotations)
+	var crConditions []interface{}
+	for _, conditionType := range conditionTypes {
+		crConditions = append(crConditions, map[string]interface{}{
+			"type":               conditionType,
+			"status":             string(metav1.ConditionTrue),
+			"reason":             "Test",
+			"lastTransitionTime": "2024-01-01T00:00:00Z",
+		})
+	}
+	if crConditions != nil {
+		_ = unstructured.SetNestedSlice(cr.Object, crConditions, "status", "conditions")
+	}
+	return cr
+}
+
+func TestIsOrphaned(t *testing.T) {
+	tests := []struct {
+		name     string
+		cr       *unstructured.Unstructured
+		orphaned bool
+	}{
+		{name: "node exists", cr: remediationCR("existing", nil)},
+		{name: "node gone", cr: remediationCR("gone", nil), orphaned: true},
+		{name: "node gone but processing", cr: remediationCR("gone", nil, conditions.ProcessingType)},
+		{name: "node gone but deletion expected", cr: remediationCR("gone", nil, conditions.PermanentNodeDeletionExpectedType)},
+		{name: "empty target node name", cr: remediationCR("gone", map[string]string{annotations.NodeNameAnnotation: ""})},
+	}
+	cl := fake.NewClientBuilder().WithObjects(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "existing"}}).Build()
+	c := NewCollector(cl, nil, nil, 0, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orphaned, reason, err := c.isOrphaned(context.Background(), tt.cr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if orphaned != tt.orphaned {
+				t.Errorf("expected orphaned %t, got %t with reason %q", tt.orphaned, orphaned, reason)
+			}
+		})
+	}
+}
+
+func TestCollectKeepsOrphansOnErrors(t *testing.T) {
+	kind := schema.GroupVersionKind{Group: "remediation.medik8s.io", Version: "v1alpha1", Kind: "TestRemediation"}
+	cr := remediationCR("gone", nil)
+	cr.SetUID("uid-1")
+
+	tests := []struct {
+		name        string
+		listFails   bool
+		getNodeFail bool
+	}{
+		{name: "list fails", listFails: true},
+		{name: "get node fails", getNodeFail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var failing bool
+			cl := fake.NewClientBuilder().WithObjects(cr.DeepCopy()).WithInterceptorFuncs(interceptor.Funcs{
+				List: func(ctx context.Context, cl client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+					if failing && tt.listFails {
+						return errors.New("list failed")
+					}
+					return cl.List(ctx, list, opts...)
+				},
+				Get: func(ctx context.Context, cl client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					if _, isNode := obj.(*corev1.Node); isNode && failing && tt.getNodeFail {
+						return errors.New("get failed")
+					}
+					return cl.Get(ctx, key, obj, opts...)
+				},
+			}).Build()
+			c := NewCollector(cl, nil, []schema.GroupVersionKind{kind}, time.Hour, 0)
+
+			if _, err := c.Collect(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			first, tracked := c.orphanedSince[types.UID("uid-1")]
+			if !tracked {
+				t.Fatalf("expected the orphan to be tracked")
+			}
+
+			failing = true
+			if _, err := c.Collect(context.Background()); err == nil {
+				t.Fatalf("expected error")
+			}
+			if o, tracked := c.orphanedSince[types.UID("uid-1")]; !tracked || !o.since.Equal(first.since) {
+				t.Errorf("expected the orphan tracking to be kept, got %+v, %t", o, tracked)
+			}
+
+			failing = false
+			if _, err := c.Collect(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if o := c.orphanedSince[types.UID("uid-1")]; !o.since.Equal(first.since) {
+				t.Errorf("expected the grace period to continue, got %+v", o)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Package handlers contains event handler helpers for watching Nodes from remediation controllers.
+package handlers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/medik8s/common/pkg/annotations"
+)
+
+// EnqueueCRsForNode returns a MapFunc which maps a Node event to reconcile requests for all remediation CRs of kind
+// crGVK which target the Node. A CR targets a Node if its node name annotation matches the Node's name, or, for CRs
+// without that annotation, if the CR is named after the Node.
+func EnqueueCRsForNode(cl client.Reader, crGVK schema.GroupVersionKind) handler.MapFunc {
+	return func(ctx context.Context, node client.Object) []reconcile.Request {
+		log := logf.FromContext(ctx).WithValues("node", node.GetName(), "kind", crGVK.Kind)
+
+		crs, err := listCRs(ctx, cl, crGVK)
+		if err != nil {
+			log.Error(err, "failed to list remediation CRs for node event")
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, cr := range crs {
+			if TargetNodeName(&cr) != node.GetName() {
+				continue
+			}
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: cr.GetNamespace(), Name: cr.GetName()},
+			})
+		}
+		return requests
+	}
+}
+
+// EnqueueCRsForNodeOfKinds is like EnqueueCRsForNode, but looks up CRs of several remediation kinds.
+// It is useful for controllers which reconcile remediation CRs of any kind, like NHC.
+func EnqueueCRsForNodeOfKinds(cl client.Reader, crGVKs ...schema.GroupVersionKind) handler.MapFunc {
+	mapFuncs := make([]handler.MapFunc, 0, len(crGVKs))
+	for _, gvk := range crGVKs {
+		mapFuncs = append(mapFuncs, EnqueueCRsForNode(cl, gvk))
+	}
+	return func(ctx context.Context, node client.Object) []reconcile.Request {
+		var requests []reconcile.Request
+		for _, mapFunc := range mapFuncs {
+			requests = append(requests, mapFunc(ctx, node)...)
+		}
+		return requests
+	}
+}
+
+// TargetNodeName returns the name of the Node targeted by the given remediation CR.
+func TargetNodeName(cr client.Object) string {
+	if nodeName, exists := cr.GetAnnotations()[annotations.NodeNameAnnotation]; exists {
+		return nodeName
+	}
+	return cr.GetName()
+}
+
+func listCRs(ctx context.Context, cl client.Reader, crGVK schema.GroupVersionKind) ([]unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(crGVK.GroupVersion().WithKind(crGVK.Kind + "List"))
+	if err := cl.List(ctx, list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
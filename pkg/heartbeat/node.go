@@ -0,0 +1,240 @@
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// BootIDFile contains the boot ID of the running kernel
+	BootIDFile = "/proc/sys/kernel/random/boot_id"
+
+	// NodeAgentLabel marks the heartbeat Leases of node agents, their holder is the node name
+	NodeAgentLabel = "heartbeat.medik8s.io/node-agent"
+	// BootIDAnnotation holds the boot ID of the node which wrote the heartbeat
+	BootIDAnnotation = "heartbeat.medik8s.io/boot-id"
+	// AgentVersionAnnotation holds the version of the node agent which wrote the heartbeat
+	AgentVersionAnnotation = "heartbeat.medik8s.io/agent-version"
+
+	nodeLeaseNamePrefix = "node-heartbeat-"
+)
+
+// NodeHeartbeat is the heartbeat record of a node agent. Each node has its own Lease, named "node-heartbeat-<node>".
+type NodeHeartbeat struct {
+	NodeName string
+	// Time is the renew time written by the agent, it is subject to the node's clock
+	Time         time.Time
+	BootID       string
+	AgentVersion string
+}
+
+// NodeRecorder writes the heartbeats of the node agent running on a node. It gives control plane side operators
+// a liveness signal which is independent of the kubelet.
+type NodeRecorder struct {
+	client       client.Client
+	namespace    string
+	nodeName     string
+	bootID       string
+	agentVersion string
+	interval     time.Duration
+}
+
+var _ manager.Runnable = &NodeRecorder{}
+var _ manager.LeaderElectionRunnable = &NodeRecorder{}
+
+// NewNodeRecorder creates a NodeRecorder writing its Lease in namespace. bootID is usually read with ReadBootID.
+// A non positive interval is replaced by DefaultInterval.
+func NewNodeRecorder(cl client.Client, namespace, nodeName, bootID, agentVersion string, interval time.Duration) *NodeRecorder {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &NodeRecorder{
+		client:       cl,
+		namespace:    namespace,
+		nodeName:     nodeName,
+		bootID:       bootID,
+		agentVersion: agentVersion,
+		interval:     interval,
+	}
+}
+
+// ReadBootID returns the boot ID of the running kernel
+func ReadBootID() (string, error) {
+	bootID, err := os.ReadFile(BootIDFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read boot ID: %w", err)
+	}
+	return strings.TrimSpace(string(bootID)), nil
+}
+
+// Start writes heartbeats until the context is cancelled. It implements manager.Runnable.
+func (r *NodeRecorder) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.Beat(ctx); err != nil {
+			log.Error(err, "failed to write node heartbeat", "node", r.nodeName)
+		}
+	}, r.interval)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, every node agent writes its own heartbeat.
+func (r *NodeRecorder) NeedLeaderElection() bool {
+	return false
+}
+
+// Beat writes a single heartbeat. Every node has its own Lease, so agents don't conflict with each other.
+func (r *NodeRecorder) Beat(ctx context.Context) error {
+	now := metav1.NowMicro()
+	durationSeconds := int32((2 * r.interval).Seconds())
+
+	lease := &coordv1.Lease{}
+	key := client.ObjectKey{Namespace: r.namespace, Name: nodeLeaseName(r.nodeName)}
+	if err := r.client.Get(ctx, key, lease); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get node heartbeat lease: %w", err)
+		}
+		lease = &coordv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: key.Namespace,
+				Name:      key.Name,
+				Labels:    map[string]string{NodeAgentLabel: "true"},
+				Annotations: map[string]string{
+					BootIDAnnotation:       r.bootID,
+					AgentVersionAnnotation: r.agentVersion,
+				},
+			},
+			Spec: coordv1.LeaseSpec{
+				HolderIdentity:       &r.nodeName,
+				LeaseDurationSeconds: &durationSeconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		if err := r.client.Create(ctx, lease); err != nil {
+			return fmt.Errorf("failed to create node heartbeat lease: %w", err)
+		}
+		return nil
+	}
+
+	if lease.Annotations == nil {
+		lease.Annotations = make(map[string]string)
+	}
+	lease.Annotations[BootIDAnnotation] = r.bootID
+	lease.Annotations[AgentVersionAnnotation] = r.agentVersion
+	lease.Spec.LeaseDurationSeconds = &durationSeconds
+	lease.Spec.RenewTime = &now
+	if err := r.client.Update(ctx, lease); err != nil {
+		return fmt.Errorf("failed to update node heartbeat lease: %w", err)
+	}
+	return nil
+}
+
+// GetNodeHeartbeats returns the heartbeats of all nodes in namespace, keyed by node name.
+func GetNodeHeartbeats(ctx context.Context, reader client.Reader, namespace string) (map[string]NodeHeartbeat, error) {
+	leases := &coordv1.LeaseList{}
+	if err := reader.List(ctx, leases, client.InNamespace(namespace), client.HasLabels{NodeAgentLabel}); err != nil {
+		return nil, fmt.Errorf("failed to list node heartbeats: %w", err)
+	}
+	heartbeats := make(map[string]NodeHeartbeat, len(leases.Items))
+	for i := range leases.Items {
+		heartbeat := toNodeHeartbeat(&leases.Items[i])
+		heartbeats[heartbeat.NodeName] = heartbeat
+	}
+	return heartbeats, nil
+}
+
+// GetNodeHeartbeat returns the heartbeat of the given node, or nil if the node never wrote one.
+func GetNodeHeartbeat(ctx context.Context, reader client.Reader, namespace, nodeName string) (*NodeHeartbeat, error) {
+	lease := &coordv1.Lease{}
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: nodeLeaseName(nodeName)}, lease); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get heartbeat of node %s: %w", nodeName, err)
+	}
+	heartbeat := toNodeHeartbeat(lease)
+	return &heartbeat, nil
+}
+
+// NodeMonitor checks whether node agents are alive. The renew times of the heartbeats are written with the nodes'
+// clocks, so they are never compared with the local clock. Instead a heartbeat counts as written when the monitor
+// observed its renew time changing. It is safe for concurrent use.
+type NodeMonitor struct {
+	reader    client.Reader
+	namespace string
+
+	lock     sync.Mutex
+	observed map[string]observation
+}
+
+type observation struct {
+	renewTime  time.Time
+	observedAt time.Time
+}
+
+// NewNodeMonitor creates a NodeMonitor for the heartbeats in namespace
+func NewNodeMonitor(reader client.Reader, namespace string) *NodeMonitor {
+	return &NodeMonitor{
+		reader:    reader,
+		namespace: namespace,
+		observed:  make(map[string]observation),
+	}
+}
+
+// IsNodeAlive returns true if the node's agent wrote a heartbeat within maxAge. The first heartbeat observed for a
+// node counts as written at the time of observation, so after a restart of the monitor nodes are alive for up to
+// maxAge. A node which never wrote a heartbeat isn't alive.
+func (m *NodeMonitor) IsNodeAlive(ctx context.Context, nodeName string, maxAge time.Duration) (bool, error) {
+	heartbeat, err := GetNodeHeartbeat(ctx, m.reader, m.namespace, nodeName)
+	if err != nil {
+		return false, err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if heartbeat == nil {
+		delete(m.observed, nodeName)
+		return false, nil
+	}
+	now := time.Now()
+	last, known := m.observed[nodeName]
+	if !known || !last.renewTime.Equal(heartbeat.Time) {
+		last = observation{renewTime: heartbeat.Time, observedAt: now}
+		m.observed[nodeName] = last
+	}
+	return now.Sub(last.observedAt) <= maxAge, nil
+}
+
+// Forget removes the node, e.g. after it was deleted
+func (m *NodeMonitor) Forget(nodeName string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.observed, nodeName)
+}
+
+func toNodeHeartbeat(lease *coordv1.Lease) NodeHeartbeat {
+	heartbeat := NodeHeartbeat{
+		NodeName:     strings.TrimPrefix(lease.Name, nodeLeaseNamePrefix),
+		BootID:       lease.Annotations[BootIDAnnotation],
+		AgentVersion: lease.Annotations[AgentVersionAnnotation],
+	}
+	if lease.Spec.RenewTime != nil {
+		heartbeat.Time = lease.Spec.RenewTime.Time
+	}
+	return heartbeat
+}
+
+func nodeLeaseName(nodeName string) string {
+	return nodeLeaseNamePrefix + nodeName
+}
@@ -0,0 +1,58 @@
+package heartbeat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNodeHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	cl := fake.NewClientBuilder().Build()
+	recorder := NewNodeRecorder(cl, "ns", "worker-0", "boot-1", "v1", time.Minute)
+
+	heartbeat, err := GetNodeHeartbeat(ctx, cl, "ns", "worker-0")
+	if err != nil || heartbeat != nil {
+		t.Fatalf("expected no heartbeat before the first beat, got %+v, %v", heartbeat, err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := recorder.Beat(ctx); err != nil {
+			t.Fatalf("beat %d failed: %v", i, err)
+		}
+	}
+	heartbeats, err := GetNodeHeartbeats(ctx, cl, "ns")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	written, exists := heartbeats["worker-0"]
+	if len(heartbeats) != 1 || !exists || written.BootID != "boot-1" || written.AgentVersion != "v1" || written.Time.IsZero() {
+		t.Errorf("expected the heartbeat of worker-0, got %+v", heartbeats)
+	}
+}
+
+func TestNodeMonitor(t *testing.T) {
+	ctx := context.Background()
+	cl := fake.NewClientBuilder().Build()
+	monitor := NewNodeMonitor(cl, "ns")
+
+	alive, err := monitor.IsNodeAlive(ctx, "worker-0", time.Minute)
+	if err != nil || alive {
+		t.Fatalf("expected a node without heartbeat not to be alive, got %t, %v", alive, err)
+	}
+	if err := NewNodeRecorder(cl, "ns", "worker-0", "boot-1", "v1", time.Minute).Beat(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	alive, err = monitor.IsNodeAlive(ctx, "worker-0", time.Minute)
+	if err != nil || !alive {
+		t.Fatalf("expected a node with a new heartbeat to be alive, got %t, %v", alive, err)
+	}
+
+	// the renew time didn't change since it was observed
+	monitor.observed["worker-0"] = observation{renewTime: monitor.observed["worker-0"].renewTime, observedAt: time.Now().Add(-2 * time.Minute)}
+	alive, err = monitor.IsNodeAlive(ctx, "worker-0", time.Minute)
+	if err != nil || alive {
+		t.Errorf("expected a node with a stale heartbeat not to be alive, got %t, %v", alive, err)
+	}
+}
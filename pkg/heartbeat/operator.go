@@ -0,0 +1,173 @@
+// Package heartbeat implements liveness heartbeats of operators and of node agents, stored in Leases, which can be
+// checked by other components.
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// VersionAnnotation holds the version of the operator which wrote the heartbeat
+	VersionAnnotation = "heartbeat.medik8s.io/version"
+	// OperatorLabel holds the name of the operator which wrote the heartbeat
+	OperatorLabel = "heartbeat.medik8s.io/operator"
+
+	// DefaultInterval is the default interval between heartbeats
+	DefaultInterval = 30 * time.Second
+
+	leaseNameSuffix = "-heartbeat"
+)
+
+var log = ctrl.Log.WithName("heartbeat")
+
+// Heartbeat is the last heartbeat written by an operator
+type Heartbeat struct {
+	Operator string
+	// Identity is the identity of the leader instance which wrote the heartbeat
+	Identity string
+	Version  string
+	Time     time.Time
+}
+
+// Age returns the time since the heartbeat was written
+func (h *Heartbeat) Age() time.Duration {
+	return time.Since(h.Time)
+}
+
+// Recorder writes heartbeats of an operator into a Lease named "<operator>-heartbeat"
+type Recorder struct {
+	client    client.Client
+	namespace string
+	operator  string
+	identity  string
+	version   string
+	interval  time.Duration
+}
+
+var _ manager.Runnable = &Recorder{}
+var _ manager.LeaderElectionRunnable = &Recorder{}
+
+// NewRecorder creates a Recorder. identity should identify the running instance, e.g. the pod name.
+// A non positive interval is replaced by DefaultInterval.
+func NewRecorder(cl client.Client, namespace, operator, identity, version string, interval time.Duration) *Recorder {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Recorder{
+		client:    cl,
+		namespace: namespace,
+		operator:  operator,
+		identity:  identity,
+		version:   version,
+		interval:  interval,
+	}
+}
+
+// Start writes heartbeats until the context is cancelled. It implements manager.Runnable.
+func (r *Recorder) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.Beat(ctx); err != nil {
+			log.Error(err, "failed to write heartbeat", "operator", r.operator)
+		}
+	}, r.interval)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, only the leader writes heartbeats.
+func (r *Recorder) NeedLeaderElection() bool {
+	return true
+}
+
+// Beat writes a single heartbeat
+func (r *Recorder) Beat(ctx context.Context) error {
+	now := metav1.NowMicro()
+	durationSeconds := int32((2 * r.interval).Seconds())
+
+	lease := &coordv1.Lease{}
+	key := client.ObjectKey{Namespace: r.namespace, Name: leaseName(r.operator)}
+	if err := r.client.Get(ctx, key, lease); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get heartbeat lease: %w", err)
+		}
+		lease = &coordv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   key.Namespace,
+				Name:        key.Name,
+				Labels:      map[string]string{OperatorLabel: r.operator},
+				Annotations: map[string]string{VersionAnnotation: r.version},
+			},
+			Spec: coordv1.LeaseSpec{
+				HolderIdentity:       &r.identity,
+				LeaseDurationSeconds: &durationSeconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		if err := r.client.Create(ctx, lease); err != nil {
+			return fmt.Errorf("failed to create heartbeat lease: %w", err)
+		}
+		return nil
+	}
+
+	if lease.Annotations == nil {
+		lease.Annotations = make(map[string]string)
+	}
+	lease.Annotations[VersionAnnotation] = r.version
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != r.identity {
+		lease.Spec.HolderIdentity = &r.identity
+		lease.Spec.AcquireTime = &now
+	}
+	lease.Spec.LeaseDurationSeconds = &durationSeconds
+	lease.Spec.RenewTime = &now
+	if err := r.client.Update(ctx, lease); err != nil {
+		return fmt.Errorf("failed to update heartbeat lease: %w", err)
+	}
+	return nil
+}
+
+// GetHeartbeat returns the last heartbeat of the given operator. It returns a NotFound error if the operator never
+// wrote a heartbeat.
+func GetHeartbeat(ctx context.Context, reader client.Reader, namespace, operator string) (*Heartbeat, error) {
+	lease := &coordv1.Lease{}
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: leaseName(operator)}, lease); err != nil {
+		return nil, err
+	}
+	heartbeat := &Heartbeat{
+		Operator: operator,
+		Version:  lease.Annotations[VersionAnnotation],
+	}
+	if lease.Spec.HolderIdentity != nil {
+		heartbeat.Identity = *lease.Spec.HolderIdentity
+	}
+	if lease.Spec.RenewTime != nil {
+		heartbeat.Time = lease.Spec.RenewTime.Time
+	}
+	return heartbeat, nil
+}
+
+// IsOperatorAlive returns true if the given operator wrote a heartbeat within maxAge.
+// An operator which never wrote a heartbeat isn't alive.
+func IsOperatorAlive(ctx context.Context, reader client.Reader, namespace, operator string, maxAge time.Duration) (bool, error) {
+	heartbeat, err := GetHeartbeat(ctx, reader, namespace, operator)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get heartbeat of %s: %w", operator, err)
+	}
+	return heartbeat.Age() <= maxAge, nil
+}
+
+func leaseName(operator string) string {
+	return operator + leaseNameSuffix
+}
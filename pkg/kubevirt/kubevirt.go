@@ -0,0 +1,133 @@
+// Package kubevirt inspects the KubeVirt virtual machines running on a node before it is remediated.
+package kubevirt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// NodeNameLabel is set by KubeVirt on VMIs and holds the name of the node they run on
+	NodeNameLabel = "kubevirt.io/nodeName"
+	// LiveMigratableCondition is the VMI condition type telling whether the VMI can be live migrated
+	LiveMigratableCondition = "LiveMigratable"
+
+	// EventReasonVMsOnNode is the reason of the event naming the VMs affected by a remediation
+	EventReasonVMsOnNode = "VirtualMachinesAffected"
+)
+
+var vmiListGVK = schema.GroupVersionKind{Group: "kubevirt.io", Version: "v1", Kind: "VirtualMachineInstanceList"}
+
+// VMI describes a virtual machine instance running on a node
+type VMI struct {
+	Namespace      string
+	Name           string
+	LiveMigratable bool
+	// Reason explains why the VMI isn't live migratable
+	Reason string
+}
+
+// String returns namespace/name
+func (v VMI) String() string {
+	return v.Namespace + "/" + v.Name
+}
+
+// NodeVMIs are the VMIs running on a node
+type NodeVMIs struct {
+	NodeName string
+	VMIs     []VMI
+}
+
+// AllLiveMigratable returns true if all VMIs can be live migrated, which is also the case if there are none.
+func (n *NodeVMIs) AllLiveMigratable() bool {
+	for _, vmi := range n.VMIs {
+		if !vmi.LiveMigratable {
+			return false
+		}
+	}
+	return true
+}
+
+// NonMigratable returns the VMIs which can't be live migrated
+func (n *NodeVMIs) NonMigratable() []VMI {
+	var result []VMI
+	for _, vmi := range n.VMIs {
+		if !vmi.LiveMigratable {
+			result = append(result, vmi)
+		}
+	}
+	return result
+}
+
+// ListVMIs returns the VMIs running on the node. A cluster without KubeVirt has no VMIs.
+func ListVMIs(ctx context.Context, cl client.Reader, nodeName string) (*NodeVMIs, error) {
+	result := &NodeVMIs{NodeName: nodeName}
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(vmiListGVK)
+	if err := cl.List(ctx, list, client.MatchingLabels{NodeNameLabel: nodeName}); err != nil {
+		if meta.IsNoMatchError(err) {
+			return result, nil
+		}
+		return nil, fmt.Errorf("failed to list VMIs on node %s: %w", nodeName, err)
+	}
+	for i := range list.Items {
+		vmi := VMI{
+			Namespace: list.Items[i].GetNamespace(),
+			Name:      list.Items[i].GetName(),
+		}
+		vmi.LiveMigratable, vmi.Reason = isLiveMigratable(&list.Items[i])
+		result.VMIs = append(result.VMIs, vmi)
+	}
+	return result, nil
+}
+
+// RecordEvent emits an event on obj, usually the remediation CR or the node, which names the affected VMIs.
+// No event is emitted if there are no VMIs on the node.
+func (n *NodeVMIs) RecordEvent(recorder record.EventRecorder, obj client.Object) {
+	if len(n.VMIs) == 0 {
+		return
+	}
+	names := make([]string, 0, len(n.VMIs))
+	for _, vmi := range n.VMIs {
+		names = append(names, vmi.String())
+	}
+	eventType := corev1.EventTypeNormal
+	message := fmt.Sprintf("Remediation of node %s affects virtual machines: %s", n.NodeName, strings.Join(names, ", "))
+	if nonMigratable := n.NonMigratable(); len(nonMigratable) > 0 {
+		eventType = corev1.EventTypeWarning
+		nonMigratableNames := make([]string, 0, len(nonMigratable))
+		for _, vmi := range nonMigratable {
+			nonMigratableNames = append(nonMigratableNames, vmi.String())
+		}
+		message += fmt.Sprintf("; not live migratable: %s", strings.Join(nonMigratableNames, ", "))
+	}
+	recorder.Event(obj, eventType, EventReasonVMsOnNode, message)
+}
+
+func isLiveMigratable(vmi *unstructured.Unstructured) (bool, string) {
+	conditions, _, _ := unstructured.NestedSlice(vmi.Object, "status", "conditions")
+	for _, condition := range conditions {
+		conditionMap, ok := condition.(map[string]interface{})
+		if !ok || conditionMap["type"] != LiveMigratableCondition {
+			continue
+		}
+		if conditionMap["status"] == string(corev1.ConditionTrue) {
+			return true, ""
+		}
+		reason, _ := conditionMap["reason"].(string)
+		message, _ := conditionMap["message"].(string)
+		if message != "" {
+			return false, fmt.Sprintf("%s: %s", reason, message)
+		}
+		return false, reason
+	}
+	return false, "LiveMigratable condition not found"
+}
@@ -0,0 +1,24 @@
+// Package lease contains helpers for coordination Leases which are shared by the other packages.
+package lease
+
+import (
+	"time"
+
+	coordv1 "k8s.io/api/coordination/v1"
+)
+
+// LeaseExpiry returns the time at which the lease expires, based on its renew time or, if it was never renewed,
+// its acquire time. It returns false if the lease has no duration or was never acquired.
+func LeaseExpiry(lease *coordv1.Lease) (time.Time, bool) {
+	if lease.Spec.LeaseDurationSeconds == nil {
+		return time.Time{}, false
+	}
+	lastUpdate := lease.Spec.RenewTime
+	if lastUpdate == nil {
+		lastUpdate = lease.Spec.AcquireTime
+	}
+	if lastUpdate == nil {
+		return time.Time{}, false
+	}
+	return lastUpdate.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second), true
+}
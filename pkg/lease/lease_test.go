@@ -0,0 +1,51 @@
+package lease
+
+import (
+	"testing"
+	"time"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLeaseExpiry(t *testing.T) {
+	acquired := metav1.NewMicroTime(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC))
+	renewed := metav1.NewMicroTime(acquired.Add(time.Minute))
+	duration := int32(30)
+
+	tests := []struct {
+		name     string
+		spec     coordv1.LeaseSpec
+		expected time.Time
+		ok       bool
+	}{
+		{
+			name:     "renewed",
+			spec:     coordv1.LeaseSpec{LeaseDurationSeconds: &duration, AcquireTime: &acquired, RenewTime: &renewed},
+			expected: renewed.Add(30 * time.Second),
+			ok:       true,
+		},
+		{
+			name:     "never renewed",
+			spec:     coordv1.LeaseSpec{LeaseDurationSeconds: &duration, AcquireTime: &acquired},
+			expected: acquired.Add(30 * time.Second),
+			ok:       true,
+		},
+		{
+			name: "no duration",
+			spec: coordv1.LeaseSpec{AcquireTime: &acquired, RenewTime: &renewed},
+		},
+		{
+			name: "never acquired",
+			spec: coordv1.LeaseSpec{LeaseDurationSeconds: &duration},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expiry, ok := LeaseExpiry(&coordv1.Lease{Spec: tt.spec})
+			if ok != tt.ok || !expiry.Equal(tt.expected) {
+				t.Errorf("expected %s, %t, got %s, %t", tt.expected, tt.ok, expiry, ok)
+			}
+		})
+	}
+}
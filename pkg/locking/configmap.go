@@ -0,0 +1,155 @@
+package locking
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+const (
+	// HolderAnnotation holds the identity of the lock holder
+	HolderAnnotation = "lock.medik8s.io/holder"
+	// RenewTimeAnnotation holds the RFC3339 time of the last acquisition or renewal
+	RenewTimeAnnotation = "lock.medik8s.io/renew-time"
+	// DurationAnnotation holds the lock duration
+	DurationAnnotation = "lock.medik8s.io/duration"
+)
+
+// AlreadyHeldError is returned when the lock is held by someone else
+type AlreadyHeldError struct {
+	Holder string
+}
+
+func (e *AlreadyHeldError) Error() string {
+	return fmt.Sprintf("can't update valid lock held by different owner %s", e.Holder)
+}
+
+type configMapLocker struct {
+	client         client.Client
+	holderIdentity string
+	namespace      string
+}
+
+var _ Locker = &configMapLocker{}
+
+// NewConfigMapLocker creates a Locker which stores locks as annotated ConfigMaps in namespace.
+// The ConfigMaps are named "<lowercase kind>-<name>", with a hash of the namespace appended for namespaced objects.
+// They are owned by the locked object if it is cluster scoped or in namespace, owner references across namespaces
+// aren't allowed.
+func NewConfigMapLocker(cl client.Client, holderIdentity, namespace string) Locker {
+	return &configMapLocker{
+		client:         cl,
+		holderIdentity: holderIdentity,
+		namespace:      namespace,
+	}
+}
+
+func (l *configMapLocker) RequestLease(ctx context.Context, obj client.Object, leaseDuration time.Duration) error {
+	name, owner, err := l.nameAndOwner(obj)
+	if err != nil {
+		return err
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := l.client.Get(ctx, client.ObjectKey{Namespace: l.namespace, Name: name}, cm); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get lock %s: %w", name, err)
+		}
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: l.namespace,
+				Name:      name,
+			},
+		}
+		if owner != nil {
+			cm.OwnerReferences = []metav1.OwnerReference{*owner}
+		}
+		l.setHolder(cm, leaseDuration)
+		if err := l.client.Create(ctx, cm); err != nil {
+			return fmt.Errorf("failed to create lock %s: %w", name, err)
+		}
+		return nil
+	}
+
+	if holder := cm.Annotations[HolderAnnotation]; holder != l.holderIdentity && isValid(cm) {
+		return &AlreadyHeldError{Holder: holder}
+	}
+	l.setHolder(cm, leaseDuration)
+	// a conflict means that someone else updated the lock in the meantime
+	if err := l.client.Update(ctx, cm); err != nil {
+		return fmt.Errorf("failed to update lock %s: %w", name, err)
+	}
+	return nil
+}
+
+func (l *configMapLocker) InvalidateLease(ctx context.Context, obj client.Object) error {
+	name, _, err := l.nameAndOwner(obj)
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{}
+	if err := l.client.Get(ctx, client.ObjectKey{Namespace: l.namespace, Name: name}, cm); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if holder := cm.Annotations[HolderAnnotation]; holder != l.holderIdentity && isValid(cm) {
+		return &AlreadyHeldError{Holder: holder}
+	}
+	if err := l.client.Delete(ctx, cm, client.Preconditions{ResourceVersion: &cm.ResourceVersion}); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	return nil
+}
+
+func (l *configMapLocker) setHolder(cm *corev1.ConfigMap, duration time.Duration) {
+	if cm.Annotations == nil {
+		cm.Annotations = make(map[string]string)
+	}
+	cm.Annotations[HolderAnnotation] = l.holderIdentity
+	cm.Annotations[RenewTimeAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	cm.Annotations[DurationAnnotation] = duration.String()
+}
+
+// nameAndOwner returns the lock name and, if the object can own the lock, the owner reference
+func (l *configMapLocker) nameAndOwner(obj client.Object) (string, *metav1.OwnerReference, error) {
+	gvk, err := apiutil.GVKForObject(obj, l.client.Scheme())
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get GVK of %s: %w", obj.GetName(), err)
+	}
+	name := strings.ToLower(gvk.Kind) + "-" + obj.GetName()
+	if obj.GetNamespace() != "" {
+		// objects with the same name in different namespaces need different locks
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(obj.GetNamespace()))
+		name = fmt.Sprintf("%s-%08x", name, hash.Sum32())
+	}
+	if obj.GetNamespace() != "" && obj.GetNamespace() != l.namespace {
+		return name, nil, nil
+	}
+	owner := &metav1.OwnerReference{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Name:       obj.GetName(),
+		UID:        obj.GetUID(),
+	}
+	return name, owner, nil
+}
+
+func isValid(cm *corev1.ConfigMap) bool {
+	renewTime, err := time.Parse(time.RFC3339, cm.Annotations[RenewTimeAnnotation])
+	if err != nil {
+		return false
+	}
+	duration, err := time.ParseDuration(cm.Annotations[DurationAnnotation])
+	if err != nil {
+		return false
+	}
+	return renewTime.Add(duration).After(time.Now())
+}
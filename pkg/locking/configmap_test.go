@@ -0,0 +1,108 @@
+package locking
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestConfigMapLockerContention(t *testing.T) {
+	ctx := context.Background()
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "uid"}}
+	cl := fake.NewClientBuilder().Build()
+	a := NewConfigMapLocker(cl, "a", "ns")
+	b := NewConfigMapLocker(cl, "b", "ns")
+
+	steps := []struct {
+		name   string
+		run    func() error
+		isHeld bool
+	}{
+		{name: "a acquires", run: func() error { return a.RequestLease(ctx, node, time.Minute) }},
+		{name: "b can't acquire", run: func() error { return b.RequestLease(ctx, node, time.Minute) }, isHeld: true},
+		{name: "a renews", run: func() error { return a.RequestLease(ctx, node, time.Minute) }},
+		{name: "b can't invalidate", run: func() error { return b.InvalidateLease(ctx, node) }, isHeld: true},
+		{name: "a invalidates", run: func() error { return a.InvalidateLease(ctx, node) }},
+		{name: "b acquires", run: func() error { return b.RequestLease(ctx, node, time.Minute) }},
+	}
+	for _, step := range steps {
+		err := step.run()
+		var held *AlreadyHeldError
+		if step.isHeld {
+			if !errors.As(err, &held) {
+				t.Fatalf("%s: expected AlreadyHeldError, got %v", step.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+	}
+}
+
+func TestConfigMapLockerExpiredLock(t *testing.T) {
+	ctx := context.Background()
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "uid"}}
+	expired := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "ns",
+		Name:      "node-node",
+		Annotations: map[string]string{
+			HolderAnnotation:    "a",
+			RenewTimeAnnotation: time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+			DurationAnnotation:  time.Minute.String(),
+		},
+	}}
+	cl := fake.NewClientBuilder().WithObjects(expired).Build()
+
+	if err := NewConfigMapLocker(cl, "b", "ns").RequestLease(ctx, node, time.Minute); err != nil {
+		t.Fatalf("expected expired lock to be taken over, got %v", err)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(expired), cm); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if holder := cm.Annotations[HolderAnnotation]; holder != "b" {
+		t.Errorf("expected holder b, got %s", holder)
+	}
+}
+
+func TestConfigMapLockerNamespacedObjects(t *testing.T) {
+	ctx := context.Background()
+	local := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod", UID: "uid-1"}}
+	other := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "pod", UID: "uid-2"}}
+	cl := fake.NewClientBuilder().Build()
+
+	if err := NewConfigMapLocker(cl, "a", "ns").RequestLease(ctx, local, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := NewConfigMapLocker(cl, "b", "ns").RequestLease(ctx, other, time.Minute); err != nil {
+		t.Fatalf("expected the lock of a pod with the same name in another namespace to be free, got %v", err)
+	}
+
+	locks := &corev1.ConfigMapList{}
+	if err := cl.List(ctx, locks, client.InNamespace("ns")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(locks.Items) != 2 {
+		t.Fatalf("expected 2 locks, got %d", len(locks.Items))
+	}
+	for _, lock := range locks.Items {
+		owned := len(lock.OwnerReferences) > 0
+		switch lock.Annotations[HolderAnnotation] {
+		case "a":
+			if !owned {
+				t.Errorf("expected the lock of the pod in the lock namespace to be owned by the pod")
+			}
+		case "b":
+			if owned {
+				t.Errorf("expected the lock of the pod in another namespace not to be owned, got %v", lock.OwnerReferences)
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Package locking provides a ConfigMap backed alternative to the medik8s lease manager, for clusters or namespaces
+// where the coordination.k8s.io API is unavailable or RBAC restricted, and the selection between both.
+package locking
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Locker has the same interface as the medik8s lease manager, so that a lease manager can be used as Locker.
+type Locker interface {
+	// RequestLease will create a lease with leaseDuration if it does not exist or extend existing lease duration to
+	// leaseDuration. It'll return an error in case it can't do either (for example if the lease is already taken).
+	RequestLease(ctx context.Context, obj client.Object, leaseDuration time.Duration) error
+	// InvalidateLease will release the lease.
+	InvalidateLease(ctx context.Context, obj client.Object) error
+}
+
+// Backend selects the Locker implementation
+type Backend string
+
+const (
+	// BackendAuto selects Lease if the API is available and permitted, and ConfigMap otherwise
+	BackendAuto Backend = ""
+	// BackendLease forces the lease manager
+	BackendLease Backend = "Lease"
+	// BackendConfigMap forces the ConfigMap locker
+	BackendConfigMap Backend = "ConfigMap"
+
+	coordinationGroupVersion = "coordination.k8s.io/v1"
+)
+
+var log = ctrl.Log.WithName("locking")
+
+// Select returns leaseLocker or configMapLocker according to backend. With BackendAuto the ConfigMap locker is
+// selected when the Lease API isn't served, or when the operator isn't allowed to manage Leases in namespace.
+func Select(ctx context.Context, disc discovery.DiscoveryInterface, cl client.Client, namespace string, backend Backend, leaseLocker, configMapLocker Locker) (Locker, error) {
+	switch backend {
+	case BackendLease:
+		return leaseLocker, nil
+	case BackendConfigMap:
+		return configMapLocker, nil
+	case BackendAuto:
+	default:
+		return nil, fmt.Errorf("unknown locking backend %q", backend)
+	}
+
+	available, err := isLeaseAPIAvailable(disc)
+	if err != nil {
+		return nil, err
+	}
+	if !available {
+		log.Info("Lease API is not available, using ConfigMap based locking")
+		return configMapLocker, nil
+	}
+	permitted, err := isLeaseAccessPermitted(ctx, cl, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if !permitted {
+		log.Info("managing Leases is not permitted, using ConfigMap based locking", "namespace", namespace)
+		return configMapLocker, nil
+	}
+	return leaseLocker, nil
+}
+
+func isLeaseAPIAvailable(disc discovery.DiscoveryInterface) (bool, error) {
+	resources, err := disc.ServerResourcesForGroupVersion(coordinationGroupVersion)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to discover %s: %w", coordinationGroupVersion, err)
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == "leases" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isLeaseAccessPermitted(ctx context.Context, cl client.Client, namespace string) (bool, error) {
+	for _, verb := range []string{"get", "create", "update", "delete"} {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: namespace,
+					Verb:      verb,
+					Group:     "coordination.k8s.io",
+					Resource:  "leases",
+				},
+			},
+		}
+		if err := cl.Create(ctx, review); err != nil {
+			return false, fmt.Errorf("failed to review lease access: %w", err)
+		}
+		if !review.Status.Allowed {
+			return false, nil
+		}
+	}
+	return true, nil
+}
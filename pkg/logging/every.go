@@ -0,0 +1,78 @@
+// Package logging contains logging helpers for hot paths.
+package logging
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Limiter logs each key at most once per interval. Suppressed messages are counted and the count is added to the
+// next message of the same key. It is safe for concurrent use.
+type Limiter struct {
+	interval time.Duration
+
+	lock    sync.Mutex
+	entries map[string]*entry
+}
+
+type entry struct {
+	lastLogged time.Time
+	suppressed int
+}
+
+// Every creates a Limiter with the given interval
+func Every(interval time.Duration) *Limiter {
+	return &Limiter{
+		interval: interval,
+		entries:  make(map[string]*entry),
+	}
+}
+
+// Info logs the message with log.Info, unless a message with the same key was logged within the interval.
+func (l *Limiter) Info(log logr.Logger, key, msg string, keysAndValues ...interface{}) {
+	if suppressed, ok := l.allow(key); ok {
+		log.Info(msg, withSuppressed(keysAndValues, suppressed)...)
+	}
+}
+
+// Error logs the error with log.Error, unless a message with the same key was logged within the interval.
+func (l *Limiter) Error(log logr.Logger, err error, key, msg string, keysAndValues ...interface{}) {
+	if suppressed, ok := l.allow(key); ok {
+		log.Error(err, msg, withSuppressed(keysAndValues, suppressed)...)
+	}
+}
+
+// Reset forgets the key, so that its next message is logged immediately
+func (l *Limiter) Reset(key string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	delete(l.entries, key)
+}
+
+func (l *Limiter) allow(key string) (int, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := time.Now()
+	e, exists := l.entries[key]
+	if !exists {
+		l.entries[key] = &entry{lastLogged: now}
+		return 0, true
+	}
+	if now.Sub(e.lastLogged) < l.interval {
+		e.suppressed++
+		return 0, false
+	}
+	suppressed := e.suppressed
+	e.lastLogged = now
+	e.suppressed = 0
+	return suppressed, true
+}
+
+func withSuppressed(keysAndValues []interface{}, suppressed int) []interface{} {
+	if suppressed == 0 {
+		return keysAndValues
+	}
+	return append(append([]interface{}(nil), keysAndValues...), "suppressed", suppressed)
+}
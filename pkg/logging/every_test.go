@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+)
+
+func TestLimiter(t *testing.T) {
+	var logged []string
+	log := funcr.New(func(prefix, args string) { logged = append(logged, args) }, funcr.Options{})
+	limiter := Every(time.Hour)
+
+	limiter.Info(log, "a", "first")
+	limiter.Info(log, "a", "suppressed")
+	limiter.Info(log, "a", "suppressed")
+	limiter.Info(log, "b", "other key")
+	if len(logged) != 2 || !strings.Contains(logged[0], "first") || !strings.Contains(logged[1], "other key") {
+		t.Fatalf("expected repeated messages of a key to be suppressed, got %v", logged)
+	}
+
+	// pretend the interval passed
+	limiter.entries["a"].lastLogged = time.Now().Add(-time.Hour)
+	limiter.Info(log, "a", "after interval")
+	if len(logged) != 3 || !strings.Contains(logged[2], `"suppressed"=2`) {
+		t.Fatalf("expected the suppressed count to be added after the interval, got %v", logged)
+	}
+	limiter.Info(log, "a", "suppressed again")
+	if len(logged) != 3 {
+		t.Fatalf("expected the message to be suppressed within the new interval, got %v", logged)
+	}
+
+	limiter.Reset("a")
+	limiter.Info(log, "a", "after reset")
+	if len(logged) != 4 || !strings.Contains(logged[3], "after reset") || strings.Contains(logged[3], "suppressed") {
+		t.Fatalf("expected the message to be logged without suppressed count after reset, got %v", logged)
+	}
+}
+
+func TestLimiterError(t *testing.T) {
+	var logged []string
+	log := funcr.New(func(prefix, args string) { logged = append(logged, args) }, funcr.Options{})
+	limiter := Every(time.Hour)
+
+	limiter.Error(log, nil, "a", "failed")
+	limiter.Info(log, "a", "shares the key")
+	if len(logged) != 1 || !strings.Contains(logged[0], "failed") {
+		t.Fatalf("expected errors and infos of a key to share the interval, got %v", logged)
+	}
+}
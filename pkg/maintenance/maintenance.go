@@ -0,0 +1,87 @@
+// Package maintenance detects nodes which were deliberately taken down for maintenance by an admin.
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	leaseutil "github.com/medik8s/common/pkg/lease"
+)
+
+const (
+	// DrainTaintKey is the taint applied by the Node Maintenance Operator to nodes in maintenance
+	DrainTaintKey = "medik8s.io/drain"
+	// LeaseNamespace is the namespace of the leases of the Node Maintenance Operator
+	LeaseNamespace = "medik8s-leases"
+	// LeaseHolderIdentity is the holder identity used by the Node Maintenance Operator
+	LeaseHolderIdentity = "node-maintenance"
+)
+
+var nodeMaintenanceGVK = schema.GroupVersionKind{Group: "nodemaintenance.medik8s.io", Version: "v1beta1", Kind: "NodeMaintenanceList"}
+
+// IsNodeUnderMaintenance returns true if an active NodeMaintenance CR targets the node, or if the node carries
+// the maintenance drain taint, or if the Node Maintenance Operator holds a valid lease for the node.
+// A cluster without the NodeMaintenance API is handled like one without NodeMaintenance CRs.
+func IsNodeUnderMaintenance(ctx context.Context, cl client.Reader, node *corev1.Node) (bool, error) {
+	if HasDrainTaint(node) {
+		return true, nil
+	}
+	if active, err := hasActiveNodeMaintenance(ctx, cl, node.Name); err != nil || active {
+		return active, err
+	}
+	return isLeaseHeldByMaintenance(ctx, cl, node.Name)
+}
+
+// HasDrainTaint returns true if the node has the maintenance drain taint
+func HasDrainTaint(node *corev1.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == DrainTaintKey {
+			return true
+		}
+	}
+	return false
+}
+
+func hasActiveNodeMaintenance(ctx context.Context, cl client.Reader, nodeName string) (bool, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(nodeMaintenanceGVK)
+	if err := cl.List(ctx, list); err != nil {
+		if meta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to list NodeMaintenances: %w", err)
+	}
+	for _, nm := range list.Items {
+		if nm.GetDeletionTimestamp() != nil {
+			continue
+		}
+		if target, _, _ := unstructured.NestedString(nm.Object, "spec", "nodeName"); target == nodeName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isLeaseHeldByMaintenance(ctx context.Context, cl client.Reader, nodeName string) (bool, error) {
+	lease := &coordv1.Lease{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: LeaseNamespace, Name: "node-" + nodeName}, lease); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get maintenance lease of node %s: %w", nodeName, err)
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != LeaseHolderIdentity {
+		return false, nil
+	}
+	dueTime, ok := leaseutil.LeaseExpiry(lease)
+	return ok && dueTime.After(time.Now()), nil
+}
@@ -0,0 +1,78 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// OpenshiftMachineAnnotation links a Node to its OpenShift Machine, in the "namespace/name" format
+	OpenshiftMachineAnnotation = "machine.openshift.io/machine"
+	// CapiMachineAnnotation links a Node to its Cluster API Machine, by name
+	CapiMachineAnnotation = "cluster.x-k8s.io/machine"
+	// CapiClusterNamespaceAnnotation holds the namespace of the Cluster API Machine
+	CapiClusterNamespaceAnnotation = "cluster.x-k8s.io/cluster-namespace"
+	// AutoscalerToBeDeletedTaint is set by the cluster autoscaler on nodes it is scaling down
+	AutoscalerToBeDeletedTaint = "ToBeDeletedByClusterAutoscaler"
+)
+
+var (
+	openshiftMachineGVK = schema.GroupVersionKind{Group: "machine.openshift.io", Version: "v1beta1", Kind: "Machine"}
+	capiMachineGVK      = schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "Machine"}
+)
+
+// IsNodeBeingDeleted returns true if the node is intentionally going away: if it has a deletion timestamp,
+// if its OpenShift or Cluster API Machine is being deleted, or if the cluster autoscaler is scaling it down.
+// A missing Machine or Machine API doesn't count as deletion.
+func IsNodeBeingDeleted(ctx context.Context, cl client.Reader, node *corev1.Node) (bool, error) {
+	if node.DeletionTimestamp != nil {
+		return true, nil
+	}
+	if isScaledDownByAutoscaler(node) {
+		return true, nil
+	}
+
+	if value, exists := node.Annotations[OpenshiftMachineAnnotation]; exists {
+		namespace, name, found := strings.Cut(value, "/")
+		if !found {
+			return false, fmt.Errorf("invalid value %q of annotation %s on node %s", value, OpenshiftMachineAnnotation, node.Name)
+		}
+		return isMachineBeingDeleted(ctx, cl, openshiftMachineGVK, client.ObjectKey{Namespace: namespace, Name: name})
+	}
+
+	if name, exists := node.Annotations[CapiMachineAnnotation]; exists {
+		namespace := node.Annotations[CapiClusterNamespaceAnnotation]
+		return isMachineBeingDeleted(ctx, cl, capiMachineGVK, client.ObjectKey{Namespace: namespace, Name: name})
+	}
+
+	return false, nil
+}
+
+func isScaledDownByAutoscaler(node *corev1.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == AutoscalerToBeDeletedTaint {
+			return true
+		}
+	}
+	return false
+}
+
+func isMachineBeingDeleted(ctx context.Context, cl client.Reader, gvk schema.GroupVersionKind, key client.ObjectKey) (bool, error) {
+	machine := &unstructured.Unstructured{}
+	machine.SetGroupVersionKind(gvk)
+	if err := cl.Get(ctx, key, machine); err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get machine %s: %w", key, err)
+	}
+	return machine.GetDeletionTimestamp() != nil, nil
+}
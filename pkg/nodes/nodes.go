@@ -0,0 +1,32 @@
+// Package nodes contains helpers for inspecting Nodes.
+package nodes
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ControlPlaneRoleLabel is the label of control plane nodes
+	ControlPlaneRoleLabel = "node-role.kubernetes.io/control-plane"
+	// MasterRoleLabel is the deprecated label of control plane nodes, still used by older clusters
+	MasterRoleLabel = "node-role.kubernetes.io/master"
+	// WorkerRoleLabel is the label of worker nodes
+	WorkerRoleLabel = "node-role.kubernetes.io/worker"
+)
+
+// IsControlPlane returns true if the node has one of the control plane role labels
+func IsControlPlane(node *corev1.Node) bool {
+	_, isControlPlane := node.Labels[ControlPlaneRoleLabel]
+	_, isMaster := node.Labels[MasterRoleLabel]
+	return isControlPlane || isMaster
+}
+
+// IsReady returns true if the node's Ready condition is True
+func IsReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
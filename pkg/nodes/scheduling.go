@@ -0,0 +1,104 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// SchedulingSnapshotAnnotation holds the scheduling state of a node from before it was prepared for remediation
+	SchedulingSnapshotAnnotation = "remediation.medik8s.io/scheduling-snapshot"
+)
+
+// SchedulingSnapshot is the scheduling state of a node before it was prepared for remediation
+type SchedulingSnapshot struct {
+	Unschedulable bool `json:"unschedulable"`
+	// TaintExisted is true if the remediation taint was already present
+	TaintExisted bool `json:"taintExisted"`
+}
+
+// PrepareForRemediation cordons the node, adds the taint and stores a snapshot of the previous state in an annotation.
+// All changes are applied in a single update, so the node is never left half prepared, even if the operator crashes.
+// Preparing an already prepared node keeps the original snapshot.
+func PrepareForRemediation(ctx context.Context, cl client.Client, nodeName string, taint corev1.Taint) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node := &corev1.Node{}
+		if err := cl.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+			return err
+		}
+		if _, prepared := node.Annotations[SchedulingSnapshotAnnotation]; prepared && node.Spec.Unschedulable && hasTaint(node, taint) {
+			return nil
+		}
+
+		if _, prepared := node.Annotations[SchedulingSnapshotAnnotation]; !prepared {
+			snapshot, err := json.Marshal(SchedulingSnapshot{
+				Unschedulable: node.Spec.Unschedulable,
+				TaintExisted:  hasTaint(node, taint),
+			})
+			if err != nil {
+				return err
+			}
+			if node.Annotations == nil {
+				node.Annotations = make(map[string]string)
+			}
+			node.Annotations[SchedulingSnapshotAnnotation] = string(snapshot)
+		}
+		node.Spec.Unschedulable = true
+		if !hasTaint(node, taint) {
+			node.Spec.Taints = append(node.Spec.Taints, taint)
+		}
+		return cl.Update(ctx, node)
+	})
+}
+
+// RestoreFromRemediation reverts PrepareForRemediation in a single update: it restores the previous schedulability,
+// removes the taint unless it existed before, and removes the snapshot. Nodes without snapshot are left untouched.
+func RestoreFromRemediation(ctx context.Context, cl client.Client, nodeName string, taint corev1.Taint) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node := &corev1.Node{}
+		if err := cl.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+			return err
+		}
+		value, prepared := node.Annotations[SchedulingSnapshotAnnotation]
+		if !prepared {
+			return nil
+		}
+		snapshot := SchedulingSnapshot{}
+		if err := json.Unmarshal([]byte(value), &snapshot); err != nil {
+			return fmt.Errorf("invalid scheduling snapshot on node %s: %w", nodeName, err)
+		}
+
+		node.Spec.Unschedulable = snapshot.Unschedulable
+		if !snapshot.TaintExisted {
+			taints := make([]corev1.Taint, 0, len(node.Spec.Taints))
+			for _, t := range node.Spec.Taints {
+				if !t.MatchTaint(&taint) {
+					taints = append(taints, t)
+				}
+			}
+			node.Spec.Taints = taints
+		}
+		delete(node.Annotations, SchedulingSnapshotAnnotation)
+		return cl.Update(ctx, node)
+	})
+}
+
+// IsPreparedForRemediation returns true if the node has a scheduling snapshot
+func IsPreparedForRemediation(node *corev1.Node) bool {
+	_, prepared := node.Annotations[SchedulingSnapshotAnnotation]
+	return prepared
+}
+
+func hasTaint(node *corev1.Node, taint corev1.Taint) bool {
+	for _, t := range node.Spec.Taints {
+		if t.MatchTaint(&taint) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package nodes
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var remediationTaint = corev1.Taint{Key: "remediation.medik8s.io/test", Effect: corev1.TaintEffectNoExecute}
+
+func getNode(t *testing.T, cl client.Client) *corev1.Node {
+	t.Helper()
+	node := &corev1.Node{}
+	if err := cl.Get(context.Background(), client.ObjectKey{Name: "node"}, node); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return node
+}
+
+func TestPrepareAndRestore(t *testing.T) {
+	otherTaint := corev1.Taint{Key: "other", Effect: corev1.TaintEffectNoSchedule}
+	tests := []struct {
+		name string
+		spec corev1.NodeSpec
+	}{
+		{name: "schedulable node without taint"},
+		{name: "cordoned node", spec: corev1.NodeSpec{Unschedulable: true}},
+		{name: "taint pre-existed", spec: corev1.NodeSpec{Taints: []corev1.Taint{remediationTaint}}},
+		{name: "other taints are kept", spec: corev1.NodeSpec{Taints: []corev1.Taint{otherTaint}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cl := fake.NewClientBuilder().WithObjects(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}, Spec: tt.spec}).Build()
+
+			if err := PrepareForRemediation(ctx, cl, "node", remediationTaint); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			node := getNode(t, cl)
+			if !IsPreparedForRemediation(node) || !node.Spec.Unschedulable || !hasTaint(node, remediationTaint) {
+				t.Fatalf("expected the node to be prepared, got %+v", node)
+			}
+			expectedTaints := len(tt.spec.Taints)
+			if !hasTaint(&corev1.Node{Spec: tt.spec}, remediationTaint) {
+				expectedTaints++
+			}
+			if len(node.Spec.Taints) != expectedTaints {
+				t.Errorf("expected the taint to be added once, got %v", node.Spec.Taints)
+			}
+
+			if err := RestoreFromRemediation(ctx, cl, "node", remediationTaint); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			node = getNode(t, cl)
+			if IsPreparedForRemediation(node) {
+				t.Errorf("expected the snapshot to be removed")
+			}
+			if node.Spec.Unschedulable != tt.spec.Unschedulable {
+				t.Errorf("expected unschedulable %t, got %t", tt.spec.Unschedulable, node.Spec.Unschedulable)
+			}
+			if len(node.Spec.Taints) != len(tt.spec.Taints) {
+				t.Errorf("expected taints %v, got %v", tt.spec.Taints, node.Spec.Taints)
+			}
+			for i := range tt.spec.Taints {
+				if !hasTaint(node, tt.spec.Taints[i]) {
+					t.Errorf("expected taint %s to be kept", tt.spec.Taints[i].Key)
+				}
+			}
+		})
+	}
+}
+
+func TestPrepareTwiceKeepsSnapshot(t *testing.T) {
+	ctx := context.Background()
+	cl := fake.NewClientBuilder().WithObjects(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}}).Build()
+	if err := PrepareForRemediation(ctx, cl, "node", remediationTaint); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	snapshot := getNode(t, cl).Annotations[SchedulingSnapshotAnnotation]
+
+	// the node is prepared again, after someone removed the taint
+	node := getNode(t, cl)
+	node.Spec.Taints = nil
+	if err := cl.Update(ctx, node); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := PrepareForRemediation(ctx, cl, "node", remediationTaint); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	node = getNode(t, cl)
+	if node.Annotations[SchedulingSnapshotAnnotation] != snapshot {
+		t.Errorf("expected the original snapshot %s, got %s", snapshot, node.Annotations[SchedulingSnapshotAnnotation])
+	}
+	if !hasTaint(node, remediationTaint) {
+		t.Errorf("expected the taint to be added again")
+	}
+
+	if err := RestoreFromRemediation(ctx, cl, "node", remediationTaint); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	node = getNode(t, cl)
+	if node.Spec.Unschedulable || hasTaint(node, remediationTaint) {
+		t.Errorf("expected the original state to be restored, got %+v", node.Spec)
+	}
+}
+
+func TestRestoreWithoutSnapshot(t *testing.T) {
+	ctx := context.Background()
+	cl := fake.NewClientBuilder().WithObjects(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node"},
+		Spec:       corev1.NodeSpec{Unschedulable: true, Taints: []corev1.Taint{remediationTaint}},
+	}).Build()
+	resourceVersion := getNode(t, cl).ResourceVersion
+
+	if err := RestoreFromRemediation(ctx, cl, "node", remediationTaint); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	node := getNode(t, cl)
+	if node.ResourceVersion != resourceVersion {
+		t.Errorf("expected the node to be left untouched")
+	}
+	if !node.Spec.Unschedulable || !hasTaint(node, remediationTaint) {
+		t.Errorf("expected the node to stay cordoned and tainted, got %+v", node.Spec)
+	}
+}
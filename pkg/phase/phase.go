@@ -0,0 +1,90 @@
+// Package phase contains a small state machine for the phase of remediation CRs,
+// which prevents illegal status transitions like Succeeded -> Processing.
+package phase
+
+import (
+	"context"
+	"fmt"
+)
+
+// Phase is a state of the machine. The empty Phase is the initial state of new objects.
+type Phase string
+
+// Hook is called when a phase is entered
+type Hook func(ctx context.Context, from, to Phase) error
+
+// IllegalTransitionError is returned when a transition isn't allowed
+type IllegalTransitionError struct {
+	From Phase
+	To   Phase
+}
+
+func (e *IllegalTransitionError) Error() string {
+	return fmt.Sprintf("illegal phase transition from %q to %q", e.From, e.To)
+}
+
+// Machine holds the allowed transitions and the on-enter hooks. It should be set up once and can then be shared.
+type Machine struct {
+	transitions map[Phase]map[Phase]bool
+	onEnter     map[Phase][]Hook
+}
+
+// NewMachine creates a Machine without any allowed transitions
+func NewMachine() *Machine {
+	return &Machine{
+		transitions: make(map[Phase]map[Phase]bool),
+		onEnter:     make(map[Phase][]Hook),
+	}
+}
+
+// Allow allows transitions from the given phase to all given target phases
+func (m *Machine) Allow(from Phase, to ...Phase) *Machine {
+	if m.transitions[from] == nil {
+		m.transitions[from] = make(map[Phase]bool)
+	}
+	for _, target := range to {
+		m.transitions[from][target] = true
+	}
+	return m
+}
+
+// OnEnter registers a hook which is called before the given phase is entered
+func (m *Machine) OnEnter(phase Phase, hook Hook) *Machine {
+	m.onEnter[phase] = append(m.onEnter[phase], hook)
+	return m
+}
+
+// CanTransition returns true if the transition is allowed. Staying in the same phase is always allowed.
+func (m *Machine) CanTransition(from, to Phase) bool {
+	return from == to || m.transitions[from][to]
+}
+
+// IsFinal returns true if no transitions out of the given phase are allowed
+func (m *Machine) IsFinal(phase Phase) bool {
+	return len(m.transitions[phase]) == 0
+}
+
+// Transition moves the phase persisted in store to the given phase. The on-enter hooks of the target phase run
+// before the store is updated, a failing hook aborts the transition. Staying in the same phase is a no-op.
+// Note that the store is only updated in memory, persisting the object's status is up to the caller.
+func (m *Machine) Transition(ctx context.Context, store Store, to Phase) error {
+	from := store.GetPhase()
+	if from == to {
+		return nil
+	}
+	if !m.CanTransition(from, to) {
+		return &IllegalTransitionError{From: from, To: to}
+	}
+	if validator, ok := store.(Validator); ok {
+		if err := validator.ValidatePhase(to); err != nil {
+			return err
+		}
+	}
+	for _, hook := range m.onEnter[to] {
+		if err := hook(ctx, from, to); err != nil {
+			return fmt.Errorf("failed to enter phase %q: %w", to, err)
+		}
+	}
+	store.SetPhase(to)
+	return nil
+}
@@ -0,0 +1,76 @@
+package phase
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+const (
+	processing Phase = "Processing"
+	succeeded  Phase = "Succeeded"
+	failed     Phase = "Failed"
+)
+
+func newMachine(entered *[]Phase, failingHook bool) *Machine {
+	return NewMachine().
+		Allow("", processing).
+		Allow(processing, succeeded, failed).
+		OnEnter(succeeded, func(ctx context.Context, from, to Phase) error {
+			*entered = append(*entered, to)
+			if failingHook {
+				return errors.New("hook failed")
+			}
+			return nil
+		})
+}
+
+func TestTransition(t *testing.T) {
+	tests := []struct {
+		name        string
+		from        Phase
+		to          Phase
+		failingHook bool
+		expected    Phase
+		illegal     bool
+		hookFailed  bool
+		entered     int
+	}{
+		{name: "initial phase", from: "", to: processing, expected: processing},
+		{name: "allowed with hook", from: processing, to: succeeded, expected: succeeded, entered: 1},
+		{name: "same phase is a no-op", from: succeeded, to: succeeded, expected: succeeded},
+		{name: "illegal", from: succeeded, to: processing, expected: succeeded, illegal: true},
+		{name: "skipping a phase is illegal", from: "", to: succeeded, expected: "", illegal: true},
+		{name: "failing hook aborts", from: processing, to: succeeded, failingHook: true, expected: processing, hookFailed: true, entered: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entered []Phase
+			field := string(tt.from)
+			err := newMachine(&entered, tt.failingHook).Transition(context.Background(), NewFieldStore(&field), tt.to)
+
+			var illegal *IllegalTransitionError
+			if errors.As(err, &illegal) != tt.illegal {
+				t.Errorf("expected illegal %t, got %v", tt.illegal, err)
+			}
+			if tt.hookFailed != (err != nil && !tt.illegal) {
+				t.Errorf("expected hook failure %t, got %v", tt.hookFailed, err)
+			}
+			if Phase(field) != tt.expected {
+				t.Errorf("expected phase %q, got %q", tt.expected, field)
+			}
+			if len(entered) != tt.entered {
+				t.Errorf("expected %d hook calls, got %v", tt.entered, entered)
+			}
+		})
+	}
+}
+
+func TestIsFinal(t *testing.T) {
+	machine := newMachine(new([]Phase), false)
+	for phase, final := range map[Phase]bool{"": false, processing: false, succeeded: true, failed: true} {
+		if machine.IsFinal(phase) != final {
+			t.Errorf("expected final %t for phase %q", final, phase)
+		}
+	}
+}
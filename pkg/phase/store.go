@@ -0,0 +1,103 @@
+package phase
+
+import (
+	"fmt"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// conditionReasonRegexp is the format of condition reasons enforced by the API server
+var conditionReasonRegexp = regexp.MustCompile(`^[A-Za-z]([A-Za-z0-9_,:]*[A-Za-z0-9_])?$`)
+
+const maxConditionReasonLength = 1024
+
+// Store reads and writes the phase of an object
+type Store interface {
+	GetPhase() Phase
+	SetPhase(phase Phase)
+}
+
+// Validator is optionally implemented by stores which can't persist every phase. Machine.Transition rejects invalid
+// target phases before running any hook.
+type Validator interface {
+	ValidatePhase(phase Phase) error
+}
+
+// FieldStore persists the phase in a status field, e.g.
+//
+//	phase.NewFieldStore(&cr.Status.Phase)
+type FieldStore struct {
+	field *string
+}
+
+// NewFieldStore creates a Store persisting the phase in the given field
+func NewFieldStore(field *string) *FieldStore {
+	return &FieldStore{field: field}
+}
+
+// GetPhase implements Store
+func (s *FieldStore) GetPhase() Phase {
+	return Phase(*s.field)
+}
+
+// SetPhase implements Store
+func (s *FieldStore) SetPhase(phase Phase) {
+	*s.field = string(phase)
+}
+
+// ConditionStore persists the phase as the reason of a dedicated status condition. Phases have to be valid condition
+// reasons, i.e. CamelCase, and the empty initial phase removes the condition.
+type ConditionStore struct {
+	conditions         *[]metav1.Condition
+	conditionType      string
+	observedGeneration int64
+}
+
+// NewConditionStore creates a Store persisting the phase in the condition of the given type
+func NewConditionStore(conditions *[]metav1.Condition, conditionType string, observedGeneration int64) *ConditionStore {
+	return &ConditionStore{
+		conditions:         conditions,
+		conditionType:      conditionType,
+		observedGeneration: observedGeneration,
+	}
+}
+
+// GetPhase implements Store
+func (s *ConditionStore) GetPhase() Phase {
+	condition := meta.FindStatusCondition(*s.conditions, s.conditionType)
+	if condition == nil {
+		return ""
+	}
+	return Phase(condition.Reason)
+}
+
+// SetPhase implements Store. Invalid phases, see ValidatePhase, would fail the status update, so they are ignored.
+func (s *ConditionStore) SetPhase(phase Phase) {
+	if phase == "" {
+		meta.RemoveStatusCondition(s.conditions, s.conditionType)
+		return
+	}
+	if s.ValidatePhase(phase) != nil {
+		return
+	}
+	meta.SetStatusCondition(s.conditions, metav1.Condition{
+		Type:               s.conditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             string(phase),
+		Message:            "phase is " + string(phase),
+		ObservedGeneration: s.observedGeneration,
+	})
+}
+
+// ValidatePhase implements Validator, the phase has to be empty or a valid condition reason
+func (s *ConditionStore) ValidatePhase(phase Phase) error {
+	if phase == "" {
+		return nil
+	}
+	if len(phase) > maxConditionReasonLength || !conditionReasonRegexp.MatchString(string(phase)) {
+		return fmt.Errorf("phase %q is not a valid condition reason, it must be CamelCase", phase)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package phase
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConditionStore(t *testing.T) {
+	var conditions []metav1.Condition
+	store := NewConditionStore(&conditions, "Phase", 2)
+	if store.GetPhase() != "" {
+		t.Errorf("expected the initial phase without condition, got %q", store.GetPhase())
+	}
+
+	store.SetPhase(processing)
+	condition := meta.FindStatusCondition(conditions, "Phase")
+	if condition == nil || condition.Reason != string(processing) || condition.ObservedGeneration != 2 || store.GetPhase() != processing {
+		t.Fatalf("expected the phase in the condition reason, got %+v", conditions)
+	}
+
+	store.SetPhase("")
+	if len(conditions) != 0 || store.GetPhase() != "" {
+		t.Errorf("expected the empty phase to remove the condition, got %+v", conditions)
+	}
+}
+
+func TestConditionStoreValidation(t *testing.T) {
+	tests := []struct {
+		phase Phase
+		valid bool
+	}{
+		{phase: "", valid: true},
+		{phase: "Processing", valid: true},
+		{phase: "Waiting_For_Node", valid: true},
+		{phase: "in progress"},
+		{phase: "1stPhase"},
+		{phase: "Processing-"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.phase), func(t *testing.T) {
+			var conditions []metav1.Condition
+			store := NewConditionStore(&conditions, "Phase", 1)
+			if err := store.ValidatePhase(tt.phase); (err == nil) != tt.valid {
+				t.Errorf("expected valid %t, got %v", tt.valid, err)
+			}
+
+			err := NewMachine().Allow("", tt.phase).Transition(context.Background(), store, tt.phase)
+			if (err == nil) != tt.valid {
+				t.Errorf("expected the transition to succeed %t, got %v", tt.valid, err)
+			}
+			if !tt.valid && len(conditions) != 0 {
+				t.Errorf("expected no condition for an invalid phase, got %+v", conditions)
+			}
+		})
+	}
+}
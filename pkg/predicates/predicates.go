@@ -0,0 +1,99 @@
+// Package predicates contains reusable controller-runtime predicates for medik8s controllers.
+package predicates
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/medik8s/common/pkg/nodes"
+)
+
+// SpecChanged returns a predicate which passes create and delete events, and update events only when the spec of
+// the object changed. Status and metadata changes are ignored.
+// Unlike predicate.GenerationChangedPredicate it also works for objects which don't bump their generation, like Nodes.
+func SpecChanged() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil {
+				return false
+			}
+			oldSpec, oldFound := getSpec(e.ObjectOld)
+			newSpec, newFound := getSpec(e.ObjectNew)
+			if !oldFound && !newFound {
+				return false
+			}
+			return !equality.Semantic.DeepEqual(oldSpec, newSpec)
+		},
+	}
+}
+
+// LabelChanged returns a predicate which passes create and delete events, and update events only when the value
+// of one of the given label keys was added, removed or modified.
+func LabelChanged(keys ...string) predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil {
+				return false
+			}
+			oldLabels, newLabels := e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels()
+			for _, key := range keys {
+				oldValue, oldFound := oldLabels[key]
+				newValue, newFound := newLabels[key]
+				if oldFound != newFound || oldValue != newValue {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// NodeBecameUnready returns a predicate which only passes Node update events where the Ready condition
+// transitioned from True to False or Unknown.
+func NodeBecameUnready() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc:  func(_ event.CreateEvent) bool { return false },
+		DeleteFunc:  func(_ event.DeleteEvent) bool { return false },
+		GenericFunc: func(_ event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldNode, ok := e.ObjectOld.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			newNode, ok := e.ObjectNew.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			return nodes.IsReady(oldNode) && !nodes.IsReady(newNode)
+		},
+	}
+}
+
+// DeletionOnly returns a predicate which only passes delete events, and update events which set the deletion timestamp.
+func DeletionOnly() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc:  func(_ event.CreateEvent) bool { return false },
+		GenericFunc: func(_ event.GenericEvent) bool { return false },
+		DeleteFunc:  func(_ event.DeleteEvent) bool { return true },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil {
+				return false
+			}
+			return e.ObjectOld.GetDeletionTimestamp() == nil && e.ObjectNew.GetDeletionTimestamp() != nil
+		},
+	}
+}
+
+func getSpec(obj client.Object) (interface{}, bool) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		// can't compare, so assume the spec did change
+		return obj, true
+	}
+	spec, found := content["spec"]
+	return spec, found
+}
@@ -0,0 +1,179 @@
+package predicates
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func unstructuredObject(spec map[string]interface{}, status map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "remediation.medik8s.io/v1alpha1",
+		"kind":       "Remediation",
+		"metadata":   map[string]interface{}{"name": "r"},
+	}}
+	if spec != nil {
+		u.Object["spec"] = spec
+	}
+	if status != nil {
+		u.Object["status"] = status
+	}
+	return u
+}
+
+func readyNode(status corev1.ConditionStatus) *corev1.Node {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}}
+	if status != "" {
+		node.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}}
+	}
+	return node
+}
+
+func TestSpecChanged(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new client.Object
+		expected bool
+	}{
+		{
+			name:     "typed spec changed",
+			old:      &corev1.Node{Spec: corev1.NodeSpec{Unschedulable: false}},
+			new:      &corev1.Node{Spec: corev1.NodeSpec{Unschedulable: true}},
+			expected: true,
+		},
+		{
+			name: "typed status changed",
+			old:  readyNode(corev1.ConditionTrue),
+			new:  readyNode(corev1.ConditionFalse),
+		},
+		{
+			name: "typed labels changed",
+			old:  &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"a": "1"}}},
+			new:  &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"a": "2"}}},
+		},
+		{
+			name:     "unstructured spec changed",
+			old:      unstructuredObject(map[string]interface{}{"strategy": "a"}, nil),
+			new:      unstructuredObject(map[string]interface{}{"strategy": "b"}, nil),
+			expected: true,
+		},
+		{
+			name: "unstructured status changed",
+			old:  unstructuredObject(map[string]interface{}{"strategy": "a"}, map[string]interface{}{"phase": "a"}),
+			new:  unstructuredObject(map[string]interface{}{"strategy": "a"}, map[string]interface{}{"phase": "b"}),
+		},
+		{
+			name:     "unstructured spec added",
+			old:      unstructuredObject(nil, nil),
+			new:      unstructuredObject(map[string]interface{}{"strategy": "a"}, nil),
+			expected: true,
+		},
+		{
+			name: "unstructured without spec",
+			old:  unstructuredObject(nil, map[string]interface{}{"phase": "a"}),
+			new:  unstructuredObject(nil, map[string]interface{}{"phase": "b"}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if passed := SpecChanged().Update(event.UpdateEvent{ObjectOld: tt.old, ObjectNew: tt.new}); passed != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, passed)
+			}
+		})
+	}
+}
+
+func TestLabelChanged(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new map[string]string
+		expected bool
+	}{
+		{name: "watched label added", new: map[string]string{"watched": "a"}, expected: true},
+		{name: "watched label removed", old: map[string]string{"watched": "a"}, expected: true},
+		{name: "watched label modified", old: map[string]string{"watched": "a"}, new: map[string]string{"watched": "b"}, expected: true},
+		{name: "empty watched label added", new: map[string]string{"watched": ""}, expected: true},
+		{name: "other label modified", old: map[string]string{"other": "a"}, new: map[string]string{"other": "b"}},
+		{name: "unchanged", old: map[string]string{"watched": "a"}, new: map[string]string{"watched": "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := event.UpdateEvent{
+				ObjectOld: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: tt.old}},
+				ObjectNew: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: tt.new}},
+			}
+			if passed := LabelChanged("watched").Update(e); passed != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, passed)
+			}
+		})
+	}
+}
+
+func TestNodeBecameUnready(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new corev1.ConditionStatus
+		expected bool
+	}{
+		{name: "ready to not ready", old: corev1.ConditionTrue, new: corev1.ConditionFalse, expected: true},
+		{name: "ready to unknown", old: corev1.ConditionTrue, new: corev1.ConditionUnknown, expected: true},
+		{name: "ready condition removed", old: corev1.ConditionTrue, expected: true},
+		{name: "stays ready", old: corev1.ConditionTrue, new: corev1.ConditionTrue},
+		{name: "not ready to ready", old: corev1.ConditionFalse, new: corev1.ConditionTrue},
+		{name: "not ready to unknown", old: corev1.ConditionFalse, new: corev1.ConditionUnknown},
+		{name: "without ready condition", new: corev1.ConditionFalse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := event.UpdateEvent{ObjectOld: readyNode(tt.old), ObjectNew: readyNode(tt.new)}
+			if passed := NodeBecameUnready().Update(e); passed != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, passed)
+			}
+		})
+	}
+
+	node := readyNode(corev1.ConditionFalse)
+	if NodeBecameUnready().Create(event.CreateEvent{Object: node}) || NodeBecameUnready().Delete(event.DeleteEvent{Object: node}) {
+		t.Errorf("expected create and delete events to be filtered")
+	}
+	pod := &corev1.Pod{}
+	if NodeBecameUnready().Update(event.UpdateEvent{ObjectOld: pod, ObjectNew: pod}) {
+		t.Errorf("expected non Node objects to be filtered")
+	}
+}
+
+func TestDeletionOnly(t *testing.T) {
+	now := metav1.Now()
+	tests := []struct {
+		name     string
+		old, new *metav1.Time
+		expected bool
+	}{
+		{name: "deletion timestamp set", new: &now, expected: true},
+		{name: "already deleting", old: &now, new: &now},
+		{name: "not deleting"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := event.UpdateEvent{
+				ObjectOld: &corev1.Node{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: tt.old}},
+				ObjectNew: &corev1.Node{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: tt.new}},
+			}
+			if passed := DeletionOnly().Update(e); passed != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, passed)
+			}
+		})
+	}
+
+	node := &corev1.Node{}
+	if !DeletionOnly().Delete(event.DeleteEvent{Object: node}) {
+		t.Errorf("expected delete events to pass")
+	}
+	if DeletionOnly().Create(event.CreateEvent{Object: node}) {
+		t.Errorf("expected create events to be filtered")
+	}
+}
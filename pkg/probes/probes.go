@@ -0,0 +1,83 @@
+// Package probes provides healthz.Checker implementations which can be wired into the
+// readiness and liveness probes of medik8s operators.
+package probes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+
+	leaseutil "github.com/medik8s/common/pkg/lease"
+)
+
+const (
+	// DefaultTimeout is the time a single check is allowed to take
+	DefaultTimeout = 5 * time.Second
+)
+
+// APIServerReachable returns a checker which fails when the API server's readyz endpoint can't be reached.
+// restClient is usually the RESTClient() of a discovery client.
+func APIServerReachable(restClient rest.Interface, timeout time.Duration) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), timeoutOrDefault(timeout))
+		defer cancel()
+		if err := restClient.Get().AbsPath("/readyz").Do(ctx).Error(); err != nil {
+			return fmt.Errorf("API server isn't reachable: %w", err)
+		}
+		return nil
+	}
+}
+
+// CacheSynced returns a checker which fails as long as the informer cache hasn't synced.
+func CacheSynced(c cache.Cache, timeout time.Duration) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), timeoutOrDefault(timeout))
+		defer cancel()
+		if !c.WaitForCacheSync(ctx) {
+			return fmt.Errorf("informer cache isn't synced")
+		}
+		return nil
+	}
+}
+
+// LeasesValid returns a checker which fails when a lease in namespace held by holderIdentity has expired.
+// An expired lease which is still held by this process indicates that lease renewal got stuck.
+func LeasesValid(reader client.Reader, namespace, holderIdentity string, timeout time.Duration) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), timeoutOrDefault(timeout))
+		defer cancel()
+		leases := &coordv1.LeaseList{}
+		if err := reader.List(ctx, leases, client.InNamespace(namespace)); err != nil {
+			return fmt.Errorf("failed to list leases: %w", err)
+		}
+		now := time.Now()
+		var expired []string
+		for _, lease := range leases.Items {
+			if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != holderIdentity {
+				continue
+			}
+			if dueTime, ok := leaseutil.LeaseExpiry(&lease); ok && dueTime.Before(now) {
+				expired = append(expired, lease.Name)
+			}
+		}
+		if len(expired) > 0 {
+			return fmt.Errorf("leases held by %s expired: %s", holderIdentity, strings.Join(expired, ", "))
+		}
+		return nil
+	}
+}
+
+func timeoutOrDefault(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return DefaultTimeout
+	}
+	return timeout
+}
@@ -0,0 +1,182 @@
+// Package promgate gates automated remediation on PromQL queries against the in-cluster Prometheus.
+package promgate
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// DefaultAddress is the address of the OpenShift in-cluster Thanos querier
+	DefaultAddress = "https://thanos-querier.openshift-monitoring.svc:9091"
+	// DefaultTokenFile is the service account token, which is sent as bearer token
+	DefaultTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	// DefaultTimeout is the default timeout of a single query
+	DefaultTimeout = 10 * time.Second
+)
+
+var log = ctrl.Log.WithName("promgate")
+
+// Query is a gate condition. It denies remediation if its result contains any sample with a non zero value,
+// so queries should be written as filters, e.g. `ceph_pg_recovering > 0`.
+type Query struct {
+	Name string
+	Expr string
+}
+
+// QueryResult is the outcome of a single query
+type QueryResult struct {
+	Name   string
+	Expr   string
+	Denied bool
+	// Values maps the label sets of the returned samples to their values
+	Values map[string]float64
+	Err    error
+}
+
+// Decision is the outcome of evaluating all queries
+type Decision struct {
+	Allowed bool
+	Results []QueryResult
+}
+
+// Reason returns a human readable explanation of a denial
+func (d *Decision) Reason() string {
+	var reasons []string
+	for _, result := range d.Results {
+		switch {
+		case result.Err != nil:
+			reasons = append(reasons, fmt.Sprintf("query %s failed: %v", result.Name, result.Err))
+		case result.Denied:
+			reasons = append(reasons, fmt.Sprintf("query %s returned %v", result.Name, result.Values))
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// Config configures a Gate
+type Config struct {
+	// Address of the Prometheus API, defaults to DefaultAddress
+	Address string
+	// TokenFile contains the bearer token, defaults to DefaultTokenFile. It is re-read on every request, so that
+	// rotated tokens are picked up.
+	TokenFile string
+	// InsecureSkipVerify disables TLS verification
+	InsecureSkipVerify bool
+	// Timeout of a single query, defaults to DefaultTimeout
+	Timeout time.Duration
+	// AllowOnError allows remediation when a query fails. By default failing queries deny remediation.
+	AllowOnError bool
+}
+
+// Gate evaluates queries
+type Gate struct {
+	api          promv1.API
+	queries      []Query
+	timeout      time.Duration
+	allowOnError bool
+}
+
+// NewGate creates a Gate evaluating the given queries
+func NewGate(config Config, queries []Query) (*Gate, error) {
+	if config.Address == "" {
+		config.Address = DefaultAddress
+	}
+	if config.TokenFile == "" {
+		config.TokenFile = DefaultTokenFile
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify}
+	promClient, err := api.NewClient(api.Config{
+		Address: config.Address,
+		RoundTripper: &bearerTokenRoundTripper{
+			tokenFile: config.TokenFile,
+			next:      transport,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create prometheus client: %w", err)
+	}
+	return &Gate{
+		api:          promv1.NewAPI(promClient),
+		queries:      queries,
+		timeout:      config.Timeout,
+		allowOnError: config.AllowOnError,
+	}, nil
+}
+
+// Evaluate runs all queries and returns whether remediation is allowed.
+func (g *Gate) Evaluate(ctx context.Context) *Decision {
+	decision := &Decision{Allowed: true}
+	for _, query := range g.queries {
+		result := g.evaluate(ctx, query)
+		if result.Denied || (result.Err != nil && !g.allowOnError) {
+			decision.Allowed = false
+		}
+		decision.Results = append(decision.Results, result)
+	}
+	if !decision.Allowed {
+		log.Info("remediation denied by prometheus gate", "reason", decision.Reason())
+	}
+	return decision
+}
+
+func (g *Gate) evaluate(ctx context.Context, query Query) QueryResult {
+	result := QueryResult{Name: query.Name, Expr: query.Expr}
+
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+	value, warnings, err := g.api.Query(ctx, query.Expr, time.Now())
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if len(warnings) > 0 {
+		log.Info("prometheus query returned warnings", "query", query.Name, "warnings", warnings)
+	}
+
+	result.Values = make(map[string]float64)
+	switch typed := value.(type) {
+	case model.Vector:
+		for _, sample := range typed {
+			result.Values[sample.Metric.String()] = float64(sample.Value)
+			if sample.Value != 0 {
+				result.Denied = true
+			}
+		}
+	case *model.Scalar:
+		result.Values[""] = float64(typed.Value)
+		result.Denied = typed.Value != 0
+	default:
+		result.Err = fmt.Errorf("unsupported result type %s, expected vector or scalar", value.Type())
+	}
+	return result
+}
+
+type bearerTokenRoundTripper struct {
+	tokenFile string
+	next      http.RoundTripper
+}
+
+func (rt *bearerTokenRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := os.ReadFile(rt.tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bearer token: %w", err)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	return rt.next.RoundTrip(req)
+}
@@ -0,0 +1,133 @@
+// Package reachability checks whether nodes are reachable over the network from the operator pod.
+package reachability
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// KubeletPort is the port of the kubelet API
+	KubeletPort = 10250
+	// SSHPort is the default SSH port
+	SSHPort = 22
+
+	// DefaultTimeout is the dial timeout used for ports without an explicit timeout
+	DefaultTimeout = 3 * time.Second
+)
+
+// Port is a TCP port to probe
+type Port struct {
+	Port int
+	// Timeout of the dial, defaults to DefaultTimeout
+	Timeout time.Duration
+}
+
+// PortResult is the probe result of a single port
+type PortResult struct {
+	Port      int
+	Reachable bool
+	Latency   time.Duration
+	Err       error
+}
+
+// Report is the reachability report of a node
+type Report struct {
+	NodeName string
+	Address  string
+	Ports    []PortResult
+}
+
+// Reachable returns true if at least one port was reachable
+func (r *Report) Reachable() bool {
+	for _, port := range r.Ports {
+		if port.Reachable {
+			return true
+		}
+	}
+	return false
+}
+
+// AllReachable returns true if all ports were reachable
+func (r *Report) AllReachable() bool {
+	for _, port := range r.Ports {
+		if !port.Reachable {
+			return false
+		}
+	}
+	return len(r.Ports) > 0
+}
+
+// DefaultPorts returns the kubelet and SSH ports with the default timeout
+func DefaultPorts() []Port {
+	return []Port{{Port: KubeletPort}, {Port: SSHPort}}
+}
+
+// ProbeNode dials all given TCP ports of the node's internal IP concurrently, and returns the results.
+// It returns an error only if the node has no usable address.
+func ProbeNode(ctx context.Context, node *corev1.Node, ports []Port) (*Report, error) {
+	address := NodeAddress(node)
+	if address == "" {
+		return nil, fmt.Errorf("node %s has no internal or external IP address", node.Name)
+	}
+
+	report := &Report{
+		NodeName: node.Name,
+		Address:  address,
+		Ports:    make([]PortResult, len(ports)),
+	}
+	var wg sync.WaitGroup
+	for i, port := range ports {
+		wg.Add(1)
+		go func(i int, port Port) {
+			defer wg.Done()
+			report.Ports[i] = probePort(ctx, address, port)
+		}(i, port)
+	}
+	wg.Wait()
+	return report, nil
+}
+
+// NodeAddress returns the node's internal IP, or its external IP if it has no internal one.
+func NodeAddress(node *corev1.Node) string {
+	var external string
+	for _, address := range node.Status.Addresses {
+		switch address.Type {
+		case corev1.NodeInternalIP:
+			return address.Address
+		case corev1.NodeExternalIP:
+			if external == "" {
+				external = address.Address
+			}
+		}
+	}
+	return external
+}
+
+func probePort(ctx context.Context, address string, port Port) PortResult {
+	timeout := port.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := PortResult{Port: port.Port}
+	dialer := &net.Dialer{}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(address, strconv.Itoa(port.Port)))
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	_ = conn.Close()
+	result.Reachable = true
+	return result
+}
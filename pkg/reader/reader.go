@@ -0,0 +1,138 @@
+// Package reader combines a cached and a direct API reader for safety critical reads,
+// where acting on a stale cache could break quorum.
+package reader
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultMaxVerified is the number of live read verifications a Reader keeps, older ones are evicted first
+const DefaultMaxVerified = 10000
+
+// Reader reads objects either from the cache or directly from the API server.
+type Reader struct {
+	cached client.Reader
+	live   client.Reader
+
+	lock sync.Mutex
+	// verified holds the resourceVersions which were confirmed by live reads, order keeps them from the least to the
+	// most recently verified
+	verified    map[string]*list.Element
+	order       *list.List
+	maxVerified int
+}
+
+type verification struct {
+	key             string
+	resourceVersion string
+	time            time.Time
+}
+
+// NewReader creates a Reader. cached is usually the manager's client, and live its APIReader.
+func NewReader(cached, live client.Reader) *Reader {
+	return &Reader{
+		cached:      cached,
+		live:        live,
+		verified:    make(map[string]*list.Element),
+		order:       list.New(),
+		maxVerified: DefaultMaxVerified,
+	}
+}
+
+// GetLive reads the object directly from the API server.
+func (r *Reader) GetLive(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if err := r.live.Get(ctx, key, obj); err != nil {
+		r.Forget(key, obj)
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	v := verification{
+		key:             cacheKey(key, obj),
+		resourceVersion: obj.GetResourceVersion(),
+		time:            time.Now(),
+	}
+	if element, exists := r.verified[v.key]; exists {
+		element.Value = v
+		r.order.MoveToBack(element)
+		return nil
+	}
+	if len(r.verified) >= r.maxVerified {
+		r.evictOldest()
+	}
+	r.verified[v.key] = r.order.PushBack(v)
+	return nil
+}
+
+// GetCachedOrLive returns the cached object if it is known to have been up to date at most maxAge ago, which is the
+// case when a live read within maxAge returned the same resourceVersion. Otherwise the object is read live.
+// A maxAge of 0 always reads live.
+func (r *Reader) GetCachedOrLive(ctx context.Context, key client.ObjectKey, obj client.Object, maxAge time.Duration) error {
+	if maxAge > 0 && r.cached.Get(ctx, key, obj) == nil {
+		r.lock.Lock()
+		element, exists := r.verified[cacheKey(key, obj)]
+		var v verification
+		if exists {
+			v = element.Value.(verification)
+		}
+		r.lock.Unlock()
+		if exists && v.resourceVersion == obj.GetResourceVersion() && time.Since(v.time) <= maxAge {
+			return nil
+		}
+	}
+	return r.GetLive(ctx, key, obj)
+}
+
+// Get reads the object from the cache, it implements client.Reader for non critical reads.
+func (r *Reader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	return r.cached.Get(ctx, key, obj, opts...)
+}
+
+// List lists objects from the cache, it implements client.Reader for non critical reads.
+func (r *Reader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return r.cached.List(ctx, list, opts...)
+}
+
+// ListLive lists objects directly from the API server.
+func (r *Reader) ListLive(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return r.live.List(ctx, list, opts...)
+}
+
+// Forget drops the live read verification of the object, it should be called when the object is deleted.
+// obj only identifies the type of the object, by its GVK if it is unstructured.
+func (r *Reader) Forget(key client.ObjectKey, obj client.Object) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	verifiedKey := cacheKey(key, obj)
+	if element, exists := r.verified[verifiedKey]; exists {
+		r.order.Remove(element)
+		delete(r.verified, verifiedKey)
+	}
+}
+
+var _ client.Reader = &Reader{}
+
+// evictOldest drops the least recently verified entry. Callers must hold the lock.
+func (r *Reader) evictOldest() {
+	oldest := r.order.Front()
+	if oldest == nil {
+		return
+	}
+	r.order.Remove(oldest)
+	delete(r.verified, oldest.Value.(verification).key)
+}
+
+func cacheKey(key client.ObjectKey, obj client.Object) string {
+	// typed objects don't reliably keep their GVK across reads, so their Go type identifies them
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return fmt.Sprintf("%s/%s", u.GroupVersionKind(), key)
+	}
+	return fmt.Sprintf("%T/%s", obj, key)
+}
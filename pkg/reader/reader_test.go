@@ -0,0 +1,79 @@
+package reader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetCachedOrLive(t *testing.T) {
+	ctx := context.Background()
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n"}}
+	cl := fake.NewClientBuilder().WithObjects(node).Build()
+	r := NewReader(cl, cl)
+	key := client.ObjectKeyFromObject(node)
+
+	if err := r.GetCachedOrLive(ctx, key, &corev1.Node{}, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, verified := r.verified[cacheKey(key, &corev1.Node{})]; !verified {
+		t.Errorf("expected live read to be recorded")
+	}
+
+	r.Forget(key, &corev1.Node{})
+	if len(r.verified) != 0 {
+		t.Errorf("expected Forget to drop the verification, got %v", r.verified)
+	}
+}
+
+func TestVerifiedIsCapped(t *testing.T) {
+	ctx := context.Background()
+	cl := fake.NewClientBuilder().WithObjects(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "a"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "b"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "c"}},
+	).Build()
+	r := NewReader(cl, cl)
+	r.maxVerified = 2
+	for _, name := range []string{"a", "b", "c"} {
+		if err := r.GetLive(ctx, client.ObjectKey{Name: name}, &corev1.Node{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(r.verified) != 2 {
+		t.Errorf("expected 2 verifications, got %d", len(r.verified))
+	}
+	if _, exists := r.verified[cacheKey(client.ObjectKey{Name: "a"}, &corev1.Node{})]; exists {
+		t.Errorf("expected the oldest verification to be evicted")
+	}
+}
+
+func TestReverifiedIsKept(t *testing.T) {
+	ctx := context.Background()
+	cl := fake.NewClientBuilder().WithObjects(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "a"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "b"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "c"}},
+	).Build()
+	r := NewReader(cl, cl)
+	r.maxVerified = 2
+	for _, name := range []string{"a", "b", "a", "c"} {
+		if err := r.GetLive(ctx, client.ObjectKey{Name: name}, &corev1.Node{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, exists := r.verified[cacheKey(client.ObjectKey{Name: "a"}, &corev1.Node{})]; !exists {
+		t.Errorf("expected the reverified object to be kept")
+	}
+	if _, exists := r.verified[cacheKey(client.ObjectKey{Name: "b"}, &corev1.Node{})]; exists {
+		t.Errorf("expected the least recently verified object to be evicted")
+	}
+	if r.order.Len() != len(r.verified) {
+		t.Errorf("expected order and verifications to match, got %d and %d", r.order.Len(), len(r.verified))
+	}
+}
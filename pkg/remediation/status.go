@@ -0,0 +1,143 @@
+// Package remediation contains helpers for working with remediation CRs of any medik8s remediator.
+package remediation
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/common/pkg/annotations"
+	"github.com/medik8s/common/pkg/conditions"
+	"github.com/medik8s/common/pkg/handlers"
+)
+
+// State is the aggregated state of a remediation CR
+type State string
+
+const (
+	// StateInProgress means the remediation hasn't finished yet
+	StateInProgress State = "InProgress"
+	// StateSucceeded means the remediation succeeded
+	StateSucceeded State = "Succeeded"
+	// StateFailed means the remediation failed
+	StateFailed State = "Failed"
+	// StateTimedOut means NHC marked the remediation as timed out
+	StateTimedOut State = "TimedOut"
+)
+
+// Summary aggregates the states of remediation CRs
+type Summary struct {
+	InProgress []corev1.ObjectReference
+	Succeeded  []corev1.ObjectReference
+	Failed     []corev1.ObjectReference
+	TimedOut   []corev1.ObjectReference
+}
+
+// Total returns the number of summarized CRs
+func (s *Summary) Total() int {
+	return len(s.InProgress) + len(s.Succeeded) + len(s.Failed) + len(s.TimedOut)
+}
+
+// Add adds the CR to the summary
+func (s *Summary) Add(cr *unstructured.Unstructured) {
+	ref := corev1.ObjectReference{
+		APIVersion: cr.GetAPIVersion(),
+		Kind:       cr.GetKind(),
+		Namespace:  cr.GetNamespace(),
+		Name:       cr.GetName(),
+		UID:        cr.GetUID(),
+	}
+	switch GetState(cr) {
+	case StateTimedOut:
+		s.TimedOut = append(s.TimedOut, ref)
+	case StateSucceeded:
+		s.Succeeded = append(s.Succeeded, ref)
+	case StateFailed:
+		s.Failed = append(s.Failed, ref)
+	default:
+		s.InProgress = append(s.InProgress, ref)
+	}
+}
+
+// GetState returns the state of the remediation CR, based on the NHC timeout annotation and the
+// Succeeded and Processing conditions. CRs without conditions are considered to be in progress.
+func GetState(cr *unstructured.Unstructured) State {
+	if _, timedOut := cr.GetAnnotations()[annotations.NhcTimedOutAnnotation]; timedOut {
+		return StateTimedOut
+	}
+	crConditions := GetConditions(cr)
+	if succeeded := meta.FindStatusCondition(crConditions, conditions.SucceededType); succeeded != nil {
+		switch succeeded.Status {
+		case metav1.ConditionTrue:
+			return StateSucceeded
+		case metav1.ConditionFalse:
+			if !meta.IsStatusConditionTrue(crConditions, conditions.ProcessingType) {
+				return StateFailed
+			}
+		}
+	}
+	return StateInProgress
+}
+
+// SummarizeForNode summarizes the remediation CRs of the given kinds which target the given node.
+func SummarizeForNode(ctx context.Context, cl client.Reader, kinds []schema.GroupVersionKind, nodeName string) (*Summary, error) {
+	return summarize(ctx, cl, kinds, nil, func(cr *unstructured.Unstructured) bool {
+		return handlers.TargetNodeName(cr) == nodeName
+	})
+}
+
+// SummarizeMatching summarizes the remediation CRs of the given kinds whose labels match the selector.
+func SummarizeMatching(ctx context.Context, cl client.Reader, kinds []schema.GroupVersionKind, selector labels.Selector) (*Summary, error) {
+	return summarize(ctx, cl, kinds, []client.ListOption{client.MatchingLabelsSelector{Selector: selector}}, nil)
+}
+
+func summarize(ctx context.Context, cl client.Reader, kinds []schema.GroupVersionKind, opts []client.ListOption, filter func(*unstructured.Unstructured) bool) (*Summary, error) {
+	summary := &Summary{}
+	for _, kind := range kinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(kind.GroupVersion().WithKind(kind.Kind + "List"))
+		if err := cl.List(ctx, list, opts...); err != nil {
+			if meta.IsNoMatchError(err) {
+				// remediator not installed
+				continue
+			}
+			return nil, fmt.Errorf("failed to list %s: %w", kind.Kind, err)
+		}
+		for i := range list.Items {
+			if filter != nil && !filter(&list.Items[i]) {
+				continue
+			}
+			summary.Add(&list.Items[i])
+		}
+	}
+	return summary, nil
+}
+
+// GetConditions returns the status conditions of the remediation CR. Malformed conditions are skipped.
+func GetConditions(cr *unstructured.Unstructured) []metav1.Condition {
+	rawConditions, found, err := unstructured.NestedSlice(cr.Object, "status", "conditions")
+	if err != nil || !found {
+		return nil
+	}
+	var result []metav1.Condition
+	for _, rawCondition := range rawConditions {
+		rawMap, ok := rawCondition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var condition metav1.Condition
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawMap, &condition); err != nil {
+			continue
+		}
+		result = append(result, condition)
+	}
+	return result
+}
@@ -0,0 +1,133 @@
+// Package requeue computes escalating RequeueAfter intervals for reconcilers.
+package requeue
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultBaseDelay is the delay of the first requeue of an object
+	DefaultBaseDelay = 5 * time.Second
+	// DefaultMaxDelay caps the delay between requeues
+	DefaultMaxDelay = 5 * time.Minute
+	// DefaultJitterFactor is the maximum fraction of the delay which is added as jitter
+	DefaultJitterFactor = 0.1
+
+	// staleFactor times the max delay without a requeue makes an object stale, it was either reconciled
+	// successfully without a Reset, or deleted
+	staleFactor = 2
+)
+
+// Escalator hands out requeue delays which double with each consecutive requeue of the same object, up to a maximum.
+// Objects which weren't requeued for twice the maximum delay are forgotten, so deleted objects don't pile up.
+// It is safe for concurrent use.
+type Escalator struct {
+	baseDelay    time.Duration
+	maxDelay     time.Duration
+	jitterFactor float64
+
+	lock      sync.Mutex
+	failures  map[client.ObjectKey]failure
+	lastPrune time.Time
+}
+
+type failure struct {
+	attempts    int
+	lastRequeue time.Time
+}
+
+// NewEscalator creates an Escalator. Non positive values are replaced by the defaults.
+func NewEscalator(baseDelay, maxDelay time.Duration, jitterFactor float64) *Escalator {
+	if baseDelay <= 0 {
+		baseDelay = DefaultBaseDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxDelay
+	}
+	if maxDelay < baseDelay {
+		maxDelay = baseDelay
+	}
+	if jitterFactor < 0 {
+		jitterFactor = DefaultJitterFactor
+	}
+	return &Escalator{
+		baseDelay:    baseDelay,
+		maxDelay:     maxDelay,
+		jitterFactor: jitterFactor,
+		failures:     make(map[client.ObjectKey]failure),
+		lastPrune:    time.Now(),
+	}
+}
+
+// Next returns the result for requeueing obj and escalates the delay for the following call.
+func (e *Escalator) Next(obj client.Object) ctrl.Result {
+	return ctrl.Result{RequeueAfter: e.NextDelay(obj)}
+}
+
+// NextDelay returns the delay for requeueing obj and escalates the delay for the following call.
+func (e *Escalator) NextDelay(obj client.Object) time.Duration {
+	key := client.ObjectKeyFromObject(obj)
+
+	now := time.Now()
+	e.lock.Lock()
+	e.prune(now)
+	attempt := 0
+	if f, exists := e.failures[key]; exists && !e.isStale(f, now) {
+		attempt = f.attempts
+	}
+	e.failures[key] = failure{attempts: attempt + 1, lastRequeue: now}
+	e.lock.Unlock()
+
+	delay := e.baseDelay
+	for i := 0; i < attempt && delay < e.maxDelay; i++ {
+		delay *= 2
+	}
+	// jitter first, so that the max delay is a hard cap
+	if e.jitterFactor > 0 {
+		delay = wait.Jitter(delay, e.jitterFactor)
+	}
+	if delay > e.maxDelay {
+		delay = e.maxDelay
+	}
+	return delay
+}
+
+// Attempts returns the number of requeues handed out for obj since the last reset.
+func (e *Escalator) Attempts(obj client.Object) int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	f, exists := e.failures[client.ObjectKeyFromObject(obj)]
+	if !exists || e.isStale(f, time.Now()) {
+		return 0
+	}
+	return f.attempts
+}
+
+// Reset forgets obj, so that its next requeue uses the base delay again. It should be called on successful reconciles.
+func (e *Escalator) Reset(obj client.Object) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	delete(e.failures, client.ObjectKeyFromObject(obj))
+}
+
+// prune drops stale objects, at most once per max delay. Callers must hold the lock.
+func (e *Escalator) prune(now time.Time) {
+	if now.Sub(e.lastPrune) < e.maxDelay {
+		return
+	}
+	e.lastPrune = now
+	for key, f := range e.failures {
+		if e.isStale(f, now) {
+			delete(e.failures, key)
+		}
+	}
+}
+
+func (e *Escalator) isStale(f failure, now time.Time) bool {
+	return now.Sub(f.lastRequeue) > staleFactor*e.maxDelay
+}
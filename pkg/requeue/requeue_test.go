@@ -0,0 +1,112 @@
+package requeue
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestNextDelay(t *testing.T) {
+	tests := []struct {
+		name      string
+		baseDelay time.Duration
+		maxDelay  time.Duration
+		expected  []time.Duration
+	}{
+		{
+			name:      "doubles until max",
+			baseDelay: time.Second,
+			maxDelay:  10 * time.Second,
+			expected:  []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second},
+		},
+		{
+			name:      "max below base",
+			baseDelay: 10 * time.Second,
+			maxDelay:  time.Second,
+			expected:  []time.Duration{10 * time.Second, 10 * time.Second},
+		},
+		{
+			name:     "defaults",
+			expected: []time.Duration{DefaultBaseDelay, 2 * DefaultBaseDelay},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEscalator(tt.baseDelay, tt.maxDelay, 0)
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"}}
+			for i, expected := range tt.expected {
+				if delay := e.NextDelay(pod); delay != expected {
+					t.Errorf("attempt %d: expected %s, got %s", i, expected, delay)
+				}
+			}
+			if attempts := e.Attempts(pod); attempts != len(tt.expected) {
+				t.Errorf("expected %d attempts, got %d", len(tt.expected), attempts)
+			}
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	e := NewEscalator(time.Second, time.Minute, 0)
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"}}
+	other := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "pod"}}
+	e.NextDelay(pod)
+	e.NextDelay(pod)
+	e.NextDelay(other)
+
+	e.Reset(pod)
+	if delay := e.NextDelay(pod); delay != time.Second {
+		t.Errorf("expected base delay after reset, got %s", delay)
+	}
+	if delay := e.NextDelay(other); delay != 2*time.Second {
+		t.Errorf("expected reset to only affect its object, got %s", delay)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	e := NewEscalator(time.Second, time.Minute, 0.5)
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"}}
+	for i := 0; i < 20; i++ {
+		e.Reset(pod)
+		if delay := e.NextDelay(pod); delay < time.Second || delay > 1500*time.Millisecond {
+			t.Errorf("delay %s out of jitter range", delay)
+		}
+	}
+}
+
+func TestJitterIsCapped(t *testing.T) {
+	e := NewEscalator(time.Second, time.Second, 0.5)
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"}}
+	for i := 0; i < 20; i++ {
+		if delay := e.NextDelay(pod); delay != time.Second {
+			t.Errorf("expected delay capped at %s, got %s", time.Second, delay)
+		}
+	}
+}
+
+func TestStaleObjectsAreForgotten(t *testing.T) {
+	e := NewEscalator(time.Second, time.Minute, 0)
+	deleted := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "deleted"}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"}}
+	e.NextDelay(deleted)
+	e.NextDelay(deleted)
+
+	// pretend the last requeue and prune happened long ago
+	past := time.Now().Add(-time.Hour)
+	e.failures[client.ObjectKeyFromObject(deleted)] = failure{attempts: 2, lastRequeue: past}
+	e.lastPrune = past
+	if attempts := e.Attempts(deleted); attempts != 0 {
+		t.Errorf("expected stale object to have no attempts, got %d", attempts)
+	}
+
+	e.NextDelay(pod)
+	if _, exists := e.failures[client.ObjectKeyFromObject(deleted)]; exists {
+		t.Errorf("expected stale object to be pruned")
+	}
+	if delay := e.NextDelay(deleted); delay != time.Second {
+		t.Errorf("expected base delay for stale object, got %s", delay)
+	}
+}
@@ -0,0 +1,273 @@
+// Package semaphore limits the number of nodes of a node pool which are remediated concurrently.
+// The slots are Leases, so the limit holds across all operators using the same namespace.
+package semaphore
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	leaseutil "github.com/medik8s/common/pkg/lease"
+)
+
+const (
+	// PoolLabel is set on slot leases and holds the pool name
+	PoolLabel = "remediation.medik8s.io/pool"
+	// OwnerAnnotation is set on slot leases and holds the identity of the operator which acquired the slot
+	OwnerAnnotation = "remediation.medik8s.io/semaphore-owner"
+
+	leaseNamePrefix = "pool-"
+)
+
+// Semaphore hands out at most limit slots per pool. A node's pool is the value of the configured pool label,
+// nodes without that label aren't limited. Slots are held by node name, and expire after the slot duration
+// unless they are renewed by acquiring them again.
+type Semaphore struct {
+	client    client.Client
+	namespace string
+	poolLabel string
+	limit     int
+	duration  time.Duration
+	identity  string
+}
+
+// NewSemaphore creates a Semaphore with slot leases in namespace. identity identifies the calling operator.
+// Leases hold whole seconds, so duration must be at least 1s and is rounded up.
+func NewSemaphore(cl client.Client, namespace, poolLabel string, limit int, duration time.Duration, identity string) (*Semaphore, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+	// slot leases hold their duration in whole seconds
+	if duration < time.Second {
+		return nil, fmt.Errorf("duration must be at least 1s, got %s", duration)
+	}
+	return &Semaphore{
+		client:    cl,
+		namespace: namespace,
+		poolLabel: poolLabel,
+		limit:     limit,
+		duration:  duration,
+		identity:  identity,
+	}, nil
+}
+
+// Acquire acquires or renews a slot of the node's pool. It returns false if all slots are held by other nodes.
+func (s *Semaphore) Acquire(ctx context.Context, node *corev1.Node) (bool, error) {
+	pool, limited := node.Labels[s.poolLabel]
+	if !limited {
+		return true, nil
+	}
+
+	slots, err := s.getSlots(ctx, pool)
+	if err != nil {
+		return false, err
+	}
+	// renew an already held slot
+	for _, slot := range slots {
+		if slot.lease != nil && holder(slot.lease) == node.Name {
+			if err := s.renew(ctx, slot.lease); err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+	// take a free slot, other callers might compete for the same one
+	for _, slot := range slots {
+		if slot.lease != nil && isValid(slot.lease) {
+			continue
+		}
+		acquired, err := s.take(ctx, pool, slot, node.Name)
+		if err != nil {
+			return false, err
+		}
+		if acquired {
+			return true, s.releaseDuplicate(ctx, pool, node.Name, slot.name)
+		}
+	}
+	return false, nil
+}
+
+// Release frees the slot held by the node, if any.
+func (s *Semaphore) Release(ctx context.Context, node *corev1.Node) error {
+	pool, limited := node.Labels[s.poolLabel]
+	if !limited {
+		return nil
+	}
+	slots, err := s.getSlots(ctx, pool)
+	if err != nil {
+		return err
+	}
+	for _, slot := range slots {
+		if slot.lease == nil || holder(slot.lease) != node.Name {
+			continue
+		}
+		if err := s.client.Delete(ctx, slot.lease, client.Preconditions{UID: &slot.lease.UID}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to release slot %s: %w", slot.name, err)
+		}
+	}
+	return nil
+}
+
+// Holders returns the names of the nodes currently holding a valid slot of the pool.
+func (s *Semaphore) Holders(ctx context.Context, pool string) ([]string, error) {
+	slots, err := s.getSlots(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+	var holders []string
+	for _, slot := range slots {
+		if slot.lease != nil && isValid(slot.lease) {
+			holders = append(holders, holder(slot.lease))
+		}
+	}
+	return holders, nil
+}
+
+type slot struct {
+	name  string
+	lease *coordv1.Lease
+}
+
+func (s *Semaphore) getSlots(ctx context.Context, pool string) ([]slot, error) {
+	slots := make([]slot, 0, s.limit)
+	for i := 0; i < s.limit; i++ {
+		name := slotName(pool, i)
+		lease := &coordv1.Lease{}
+		if err := s.client.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: name}, lease); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("failed to get slot %s: %w", name, err)
+			}
+			lease = nil
+		}
+		slots = append(slots, slot{name: name, lease: lease})
+	}
+	return slots, nil
+}
+
+func (s *Semaphore) take(ctx context.Context, pool string, slot slot, nodeName string) (bool, error) {
+	now := metav1.NowMicro()
+	durationSeconds := s.durationSeconds()
+	if slot.lease == nil {
+		lease := &coordv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   s.namespace,
+				Name:        slot.name,
+				Labels:      map[string]string{PoolLabel: pool},
+				Annotations: map[string]string{OwnerAnnotation: s.identity},
+			},
+			Spec: coordv1.LeaseSpec{
+				HolderIdentity:       &nodeName,
+				LeaseDurationSeconds: &durationSeconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		if err := s.client.Create(ctx, lease); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to create slot %s: %w", slot.name, err)
+		}
+		return true, nil
+	}
+
+	lease := slot.lease.DeepCopy()
+	if lease.Annotations == nil {
+		lease.Annotations = make(map[string]string)
+	}
+	lease.Annotations[OwnerAnnotation] = s.identity
+	lease.Spec.HolderIdentity = &nodeName
+	lease.Spec.LeaseDurationSeconds = &durationSeconds
+	lease.Spec.AcquireTime = &now
+	lease.Spec.RenewTime = &now
+	transitions := int32(1)
+	if lease.Spec.LeaseTransitions != nil {
+		transitions = *lease.Spec.LeaseTransitions + 1
+	}
+	lease.Spec.LeaseTransitions = &transitions
+	// the update fails with a conflict if another caller took the slot in the meantime
+	if err := s.client.Update(ctx, lease); err != nil {
+		if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to take slot %s: %w", slot.name, err)
+	}
+	return true, nil
+}
+
+// releaseDuplicate releases the taken slot if a concurrent caller acquired another slot of the pool for the same node,
+// so that a node never holds more than one slot. The slot with the lowest index is kept.
+func (s *Semaphore) releaseDuplicate(ctx context.Context, pool, nodeName, takenSlot string) error {
+	slots, err := s.getSlots(ctx, pool)
+	if err != nil {
+		return err
+	}
+	for _, slot := range slots {
+		if slot.lease == nil || holder(slot.lease) != nodeName {
+			continue
+		}
+		if slot.name == takenSlot {
+			// the taken slot is the one to keep
+			return nil
+		}
+		break
+	}
+	for _, slot := range slots {
+		if slot.name != takenSlot || slot.lease == nil || holder(slot.lease) != nodeName {
+			continue
+		}
+		if err := s.client.Delete(ctx, slot.lease, client.Preconditions{UID: &slot.lease.UID}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to release duplicate slot %s: %w", slot.name, err)
+		}
+	}
+	return nil
+}
+
+func (s *Semaphore) renew(ctx context.Context, lease *coordv1.Lease) error {
+	now := metav1.NowMicro()
+	durationSeconds := s.durationSeconds()
+	lease = lease.DeepCopy()
+	lease.Spec.RenewTime = &now
+	lease.Spec.LeaseDurationSeconds = &durationSeconds
+	if err := s.client.Update(ctx, lease); err != nil {
+		return fmt.Errorf("failed to renew slot %s: %w", lease.Name, err)
+	}
+	return nil
+}
+
+func holder(lease *coordv1.Lease) string {
+	if lease.Spec.HolderIdentity == nil || !isValid(lease) {
+		return ""
+	}
+	return *lease.Spec.HolderIdentity
+}
+
+func isValid(lease *coordv1.Lease) bool {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		return false
+	}
+	dueTime, ok := leaseutil.LeaseExpiry(lease)
+	return ok && dueTime.After(time.Now())
+}
+
+// slotName returns a valid lease name for the slot. Pool names which only differ in case or in '_' and '-' map to the
+// same sanitized name, so a hash of the original pool name keeps them apart.
+func slotName(pool string, index int) string {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(pool))
+	sanitized := strings.ToLower(strings.ReplaceAll(pool, "_", "-"))
+	return fmt.Sprintf("%s%s-%08x-%d", leaseNamePrefix, sanitized, hash.Sum32(), index)
+}
+
+// durationSeconds returns the slot duration rounded up to whole seconds, so slots never expire early
+func (s *Semaphore) durationSeconds() int32 {
+	return int32((s.duration + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,195 @@
+package semaphore
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const poolLabel = "pool"
+
+func node(name, pool string) *corev1.Node {
+	n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if pool != "" {
+		n.Labels = map[string]string{poolLabel: pool}
+	}
+	return n
+}
+
+func TestAcquire(t *testing.T) {
+	tests := []struct {
+		name string
+		// acquire are the nodes acquiring a slot in order, with the expected results
+		acquire  []*corev1.Node
+		expected []bool
+		holders  []string
+	}{
+		{
+			name:     "limit reached",
+			acquire:  []*corev1.Node{node("a", "p"), node("b", "p"), node("c", "p")},
+			expected: []bool{true, true, false},
+			holders:  []string{"a", "b"},
+		},
+		{
+			name:     "renewal doesn't take another slot",
+			acquire:  []*corev1.Node{node("a", "p"), node("a", "p"), node("b", "p")},
+			expected: []bool{true, true, true},
+			holders:  []string{"a", "b"},
+		},
+		{
+			name:     "pools are independent",
+			acquire:  []*corev1.Node{node("a", "p"), node("b", "p"), node("c", "q")},
+			expected: []bool{true, true, true},
+			holders:  []string{"a", "b"},
+		},
+		{
+			name:     "nodes without pool aren't limited",
+			acquire:  []*corev1.Node{node("a", "p"), node("b", "p"), node("c", "")},
+			expected: []bool{true, true, true},
+			holders:  []string{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, err := NewSemaphore(fake.NewClientBuilder().Build(), "ns", poolLabel, 2, time.Minute, "test")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for i, n := range tt.acquire {
+				acquired, err := s.Acquire(ctx, n)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if acquired != tt.expected[i] {
+					t.Errorf("acquire %d of node %s: expected %t, got %t", i, n.Name, tt.expected[i], acquired)
+				}
+			}
+			holders, err := s.Holders(ctx, "p")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			sort.Strings(holders)
+			if len(holders) != len(tt.holders) || holders[0] != tt.holders[0] || holders[1] != tt.holders[1] {
+				t.Errorf("expected holders %v, got %v", tt.holders, holders)
+			}
+		})
+	}
+}
+
+func TestRelease(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewSemaphore(fake.NewClientBuilder().Build(), "ns", poolLabel, 1, time.Minute, "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a, b := node("a", "p"), node("b", "p")
+	if acquired, err := s.Acquire(ctx, a); err != nil || !acquired {
+		t.Fatalf("expected a to acquire the slot, got %t, %v", acquired, err)
+	}
+	if acquired, err := s.Acquire(ctx, b); err != nil || acquired {
+		t.Fatalf("expected b not to acquire the slot, got %t, %v", acquired, err)
+	}
+	if err := s.Release(ctx, a); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if acquired, err := s.Acquire(ctx, b); err != nil || !acquired {
+		t.Fatalf("expected b to acquire the released slot, got %t, %v", acquired, err)
+	}
+}
+
+func TestSimilarPoolNamesDontCollide(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewSemaphore(fake.NewClientBuilder().Build(), "ns", poolLabel, 1, time.Minute, "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if acquired, err := s.Acquire(ctx, node("a", "pool_a")); err != nil || !acquired {
+		t.Fatalf("expected a to acquire a slot, got %t, %v", acquired, err)
+	}
+	if acquired, err := s.Acquire(ctx, node("b", "Pool-a")); err != nil || !acquired {
+		t.Errorf("expected b to acquire a slot of its own pool, got %t, %v", acquired, err)
+	}
+}
+
+func TestReleaseDuplicate(t *testing.T) {
+	tests := []struct {
+		name    string
+		taken   int
+		holders []string
+	}{
+		{name: "later slot is released", taken: 1, holders: []string{"a"}},
+		{name: "first slot is kept", taken: 0, holders: []string{"a", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, err := NewSemaphore(fake.NewClientBuilder().Build(), "ns", poolLabel, 2, time.Minute, "test")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// two callers acquired a slot for the same node concurrently
+			for i := 0; i < 2; i++ {
+				if acquired, err := s.take(ctx, "p", slot{name: slotName("p", i)}, "a"); err != nil || !acquired {
+					t.Fatalf("failed to take slot %d: %t, %v", i, acquired, err)
+				}
+			}
+			if err := s.releaseDuplicate(ctx, "p", "a", slotName("p", tt.taken)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			holders, err := s.Holders(ctx, "p")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(holders) != len(tt.holders) {
+				t.Errorf("expected holders %v, got %v", tt.holders, holders)
+			}
+		})
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		valid    bool
+		expected int32
+	}{
+		{name: "sub second duration is rejected", duration: 500 * time.Millisecond},
+		{name: "whole seconds", duration: time.Minute, valid: true, expected: 60},
+		{name: "fractional seconds are rounded up", duration: 1500 * time.Millisecond, valid: true, expected: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cl := fake.NewClientBuilder().Build()
+			s, err := NewSemaphore(cl, "ns", poolLabel, 1, tt.duration, "test")
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("expected duration %s to be rejected", tt.duration)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if acquired, err := s.Acquire(ctx, node("a", "p")); err != nil || !acquired {
+				t.Fatalf("expected a to acquire a slot, got %t, %v", acquired, err)
+			}
+			lease := &coordv1.Lease{}
+			if err := cl.Get(ctx, client.ObjectKey{Namespace: "ns", Name: slotName("p", 0)}, lease); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if lease.Spec.LeaseDurationSeconds == nil || *lease.Spec.LeaseDurationSeconds != tt.expected {
+				t.Errorf("expected lease duration %ds, got %v", tt.expected, lease.Spec.LeaseDurationSeconds)
+			}
+		})
+	}
+}
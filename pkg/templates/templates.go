@@ -0,0 +1,130 @@
+// Package templates discovers the remediation template kinds installed in the cluster and their instances.
+package templates
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// TemplateSuffix is the suffix of the kind of every remediation template
+	TemplateSuffix = "Template"
+	// RemediationCategory is the CRD category which remediation template CRDs are expected to carry
+	RemediationCategory = "medik8s-remediation"
+	// DefaultTemplateAnnotation marks a template instance as the default one of its kind, when set to "true"
+	DefaultTemplateAnnotation = "remediation.medik8s.io/default-template"
+)
+
+// TemplateKind describes an installed remediation template kind
+type TemplateKind struct {
+	// CRDName is the name of the CRD defining the kind
+	CRDName string
+	// GVK is the GroupVersionKind of the template, using the CRD's storage version
+	GVK schema.GroupVersionKind
+	// Instances are all instances of the template kind
+	Instances []unstructured.Unstructured
+	// Defaults are the instances marked as default
+	Defaults []types.NamespacedName
+}
+
+// RemediationGVK returns the GVK of the remediation CRs created from templates of this kind
+func (t *TemplateKind) RemediationGVK() schema.GroupVersionKind {
+	return t.GVK.GroupVersion().WithKind(strings.TrimSuffix(t.GVK.Kind, TemplateSuffix))
+}
+
+// DiscoverKinds returns all installed remediation template kinds, without instances.
+// The client's scheme needs to know the apiextensions.k8s.io/v1 types.
+func DiscoverKinds(ctx context.Context, cl client.Reader) ([]TemplateKind, error) {
+	crds := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := cl.List(ctx, crds); err != nil {
+		return nil, fmt.Errorf("failed to list CRDs: %w", err)
+	}
+
+	var kinds []TemplateKind
+	for _, crd := range crds.Items {
+		if !IsRemediationTemplateCRD(&crd) {
+			continue
+		}
+		version := storageVersion(&crd)
+		if version == "" {
+			continue
+		}
+		kinds = append(kinds, TemplateKind{
+			CRDName: crd.Name,
+			GVK: schema.GroupVersionKind{
+				Group:   crd.Spec.Group,
+				Version: version,
+				Kind:    crd.Spec.Names.Kind,
+			},
+		})
+	}
+	return kinds, nil
+}
+
+// Discover returns all installed remediation template kinds, including their instances and default markers.
+func Discover(ctx context.Context, cl client.Reader) ([]TemplateKind, error) {
+	kinds, err := DiscoverKinds(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+	for i := range kinds {
+		if err := loadInstances(ctx, cl, &kinds[i]); err != nil {
+			return nil, err
+		}
+	}
+	return kinds, nil
+}
+
+// IsRemediationTemplateCRD returns true if the CRD's kind ends with the template suffix, and if it has the
+// remediation category.
+func IsRemediationTemplateCRD(crd *apiextensionsv1.CustomResourceDefinition) bool {
+	if !strings.HasSuffix(crd.Spec.Names.Kind, TemplateSuffix) {
+		return false
+	}
+	for _, category := range crd.Spec.Names.Categories {
+		if category == RemediationCategory {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDefault returns true if the template instance is marked as default
+func IsDefault(template client.Object) bool {
+	return template.GetAnnotations()[DefaultTemplateAnnotation] == "true"
+}
+
+func loadInstances(ctx context.Context, cl client.Reader, kind *TemplateKind) error {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(kind.GVK.GroupVersion().WithKind(kind.GVK.Kind + "List"))
+	if err := cl.List(ctx, list); err != nil {
+		return fmt.Errorf("failed to list %s instances: %w", kind.GVK.Kind, err)
+	}
+	kind.Instances = list.Items
+	kind.Defaults = nil
+	for i := range list.Items {
+		if IsDefault(&list.Items[i]) {
+			kind.Defaults = append(kind.Defaults, types.NamespacedName{
+				Namespace: list.Items[i].GetNamespace(),
+				Name:      list.Items[i].GetName(),
+			})
+		}
+	}
+	return nil
+}
+
+func storageVersion(crd *apiextensionsv1.CustomResourceDefinition) string {
+	for _, version := range crd.Spec.Versions {
+		if version.Storage {
+			return version.Name
+		}
+	}
+	return ""
+}
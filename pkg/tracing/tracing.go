@@ -0,0 +1,44 @@
+// Package tracing contains the optional OpenTelemetry instrumentation of this library.
+// Spans are dropped unless the consumer sets a TracerProvider, usually the one of its OTel SDK setup.
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const instrumentationName = "github.com/medik8s/common"
+
+var (
+	lock   sync.RWMutex
+	tracer trace.Tracer = noop.NewTracerProvider().Tracer(instrumentationName)
+)
+
+// SetTracerProvider enables tracing with the given provider
+func SetTracerProvider(provider trace.TracerProvider) {
+	lock.Lock()
+	defer lock.Unlock()
+	tracer = provider.Tracer(instrumentationName)
+}
+
+// Start starts a span with the given name and attributes
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	lock.RLock()
+	t := tracer
+	lock.RUnlock()
+	return t.Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End records err on the span, if set, and ends the span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}